@@ -0,0 +1,224 @@
+package flaeg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// argRest is the tag `arg` of the field receiving the remaining positional arguments
+const argRest = "rest"
+
+// argField is a field of a config receiving a positional argument
+type argField struct {
+	// name is the name of the argument in the usage, the field name in lower case
+	name     string
+	field    reflect.StructField
+	value    reflect.Value
+	position int
+	rest     bool
+}
+
+// usage returns the argument as shown in the usage line: <name>, <name...> or [<name...>]
+func (a argField) usage() string {
+	switch {
+	case !a.rest:
+		return "<" + a.name + ">"
+	case a.field.Tag.Get("required") == "true":
+		return "<" + a.name + "...>"
+	default:
+		return "[<" + a.name + "...>]"
+	}
+}
+
+// isFlagField returns true if the field is flagged: it has a description and does not receive a positional argument
+func isFlagField(field reflect.StructField) bool {
+	_, isArg := field.Tag.Lookup("arg")
+	return len(field.Tag.Get("description")) > 0 && !isArg
+}
+
+// getArgFields returns the fields of config tagged `arg:"<position>"` sorted by position,
+// followed by the field tagged `arg:"rest"` if any.
+// Positions must start at 0 and follow each other.
+func getArgFields(config interface{}) ([]argField, error) {
+	objValue := reflect.ValueOf(config)
+	for objValue.Kind() == reflect.Ptr && !objValue.IsNil() {
+		objValue = objValue.Elem()
+	}
+	if objValue.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var fields []argField
+	var rest *argField
+	for i := 0; i < objValue.NumField(); i++ {
+		field := objValue.Type().Field(i)
+		tag, ok := field.Tag.Lookup("arg")
+		if !ok {
+			continue
+		}
+		if !isExported(field.Name) {
+			return nil, fmt.Errorf("field %s is an unexported field", field.Name)
+		}
+
+		arg := argField{name: strings.ToLower(field.Name), field: field, value: objValue.Field(i)}
+		if tag == argRest {
+			if rest != nil {
+				return nil, fmt.Errorf("fields %s and %s are both tagged arg:%q", rest.field.Name, field.Name, argRest)
+			}
+			arg.rest = true
+			rest = &arg
+			continue
+		}
+
+		position, err := strconv.Atoi(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag arg:%q on field %s", tag, field.Name)
+		}
+		arg.position = position
+		fields = append(fields, arg)
+	}
+
+	if err := sortArgFields(fields); err != nil {
+		return nil, err
+	}
+
+	if rest != nil {
+		fields = append(fields, *rest)
+	}
+	return fields, nil
+}
+
+// sortArgFields sorts the positional fields by position, and checks that positions start at 0 and follow each other
+func sortArgFields(fields []argField) error {
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].position < fields[j].position
+	})
+	for i, arg := range fields {
+		if arg.position != i {
+			return fmt.Errorf("missing field tagged arg:\"%d\"", i)
+		}
+	}
+	return nil
+}
+
+// argumentHelp is the description of a positional argument in the help
+type argumentHelp struct {
+	Name        string
+	Description string
+}
+
+// argumentsHelp returns the usage of the positional arguments of config, and the descriptions of the arguments
+func argumentsHelp(config interface{}) (string, []argumentHelp, error) {
+	argFields, err := getArgFields(config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var arguments []string
+	var descriptions []argumentHelp
+	for _, arg := range argFields {
+		arguments = append(arguments, arg.usage())
+		if description := arg.field.Tag.Get("description"); len(description) > 0 {
+			descriptions = append(descriptions, argumentHelp{Name: arg.usage(), Description: description})
+		}
+	}
+	return strings.Join(arguments, " "), descriptions, nil
+}
+
+// setArgs sets the positional arguments args on the fields, using their parsers.
+// Every positional field requires an argument, the rest field requires one if it is tagged `required:"true"`.
+func setArgs(fields []argField, args []string, parsers map[reflect.Type]parse.Parser) error {
+	var rest *argField
+	if len(fields) > 0 && fields[len(fields)-1].rest {
+		rest = &fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+
+	if err := checkArgsCount(fields, rest, args); err != nil {
+		return err
+	}
+
+	for i, arg := range fields {
+		if err := setArg(arg, args[i:i+1], parsers); err != nil {
+			return err
+		}
+	}
+	if rest != nil && len(args) > len(fields) {
+		return setArg(*rest, args[len(fields):], parsers)
+	}
+	return nil
+}
+
+// checkArgsCount checks that args has an argument for every positional field, and no extra argument if there is no rest field
+func checkArgsCount(fields []argField, rest *argField, args []string) error {
+	if len(args) < len(fields) {
+		return fields[len(args)].missingError()
+	}
+	if rest == nil && len(args) > len(fields) {
+		return &TooManyArgumentsError{Args: args[len(fields):]}
+	}
+	if rest != nil && len(args) == len(fields) && rest.field.Tag.Get("required") == "true" {
+		return rest.missingError()
+	}
+	return nil
+}
+
+func (a argField) missingError() error {
+	return &MissingArgumentError{Argument: a.usage(), Field: a.field.Name}
+}
+
+func (a argField) invalidError(value string, err error) error {
+	return &InvalidArgumentError{Argument: a.usage(), Field: a.field.Name, Value: value, Err: err}
+}
+
+// setArg sets the values on the field of arg.
+// A slice field receives one element per value, parsed by the parser of the elements: values are not split.
+// Otherwise, the parser of the field is set with every value.
+func setArg(arg argField, values []string, parsers map[reflect.Type]parse.Parser) error {
+	if arg.field.Type.Kind() == reflect.Slice {
+		if _, ok := findParser(parsers, arg.field.Type.Elem()); ok {
+			return setSliceArg(arg, values, parsers)
+		}
+	}
+
+	parser, ok := findParser(parsers, arg.field.Type)
+	if !ok {
+		return arg.invalidError(values[0], ErrParserNotFound)
+	}
+
+	parser = cloneParser(parser)
+	parser.SetValue(reflect.Zero(arg.field.Type).Interface())
+	for _, value := range values {
+		if err := parser.Set(value); err != nil {
+			return arg.invalidError(value, err)
+		}
+	}
+	return setFields(arg.value, parser)
+}
+
+// setSliceArg sets the values on the slice field of arg, every value is an element parsed by the parser of the elements
+func setSliceArg(arg argField, values []string, parsers map[reflect.Type]parse.Parser) error {
+	elemType := arg.field.Type.Elem()
+	elemParser, _ := findParser(parsers, elemType)
+
+	slice := reflect.MakeSlice(arg.field.Type, 0, len(values))
+	for _, value := range values {
+		elem := cloneParser(elemParser)
+		elem.SetValue(reflect.Zero(elemType).Interface())
+		if err := elem.Set(value); err != nil {
+			return arg.invalidError(value, err)
+		}
+		slice = reflect.Append(slice, parserValue(elem).Convert(elemType))
+	}
+
+	if !arg.value.CanSet() {
+		return fmt.Errorf("%s is not settable", arg.field.Type)
+	}
+	arg.value.Set(slice)
+	return nil
+}
@@ -0,0 +1,285 @@
+package flaeg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/containous/flaeg/parse"
+)
+
+type CopyConfig struct {
+	Force bool     `short:"f" description:"Overwrite the destinations"`
+	Src   string   `arg:"0" description:"Source file"`
+	Dst   []string `arg:"rest" required:"true" description:"Destination files"`
+}
+
+type MoveConfig struct {
+	Count int    `arg:"1"`
+	Src   string `arg:"0"`
+}
+
+func TestFlaegRunArgs(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		args        []string
+		expected    interface{}
+		expectedErr string
+	}{
+		{
+			desc:     "positional and rest",
+			args:     []string{"copy", "src", "dst1", "--force", "dst2"},
+			expected: &CopyConfig{Force: true, Src: "src", Dst: []string{"dst1", "dst2"}},
+		},
+		{
+			desc:     "case is kept",
+			args:     []string{"copy", "-f", "My-File.txt", "Dst"},
+			expected: &CopyConfig{Force: true, Src: "My-File.txt", Dst: []string{"Dst"}},
+		},
+		{
+			desc:     "after dashes",
+			args:     []string{"copy", "--", "--src", "dst"},
+			expected: &CopyConfig{Src: "--src", Dst: []string{"dst"}},
+		},
+		{
+			desc:     "separators in values",
+			args:     []string{"copy", "a.txt", "b,c.txt", "d;e"},
+			expected: &CopyConfig{Src: "a.txt", Dst: []string{"b,c.txt", "d;e"}},
+		},
+		{
+			desc:        "missing argument",
+			args:        []string{"copy"},
+			expectedErr: "missing argument <src>",
+		},
+		{
+			desc:        "missing rest argument",
+			args:        []string{"copy", "src"},
+			expectedErr: "missing argument <dst...>",
+		},
+		{
+			desc:     "typed arguments",
+			args:     []string{"move", "src", "3"},
+			expected: &MoveConfig{Src: "src", Count: 3},
+		},
+		{
+			desc:        "invalid argument",
+			args:        []string{"move", "src", "three"},
+			expectedErr: `invalid argument "three" for <count>`,
+		},
+		{
+			desc:        "too many arguments",
+			args:        []string{"move", "src", "3", "4"},
+			expectedErr: "too many arguments: 4",
+		},
+		{
+			desc:        "command without arguments",
+			args:        []string{"version", "src"},
+			expectedErr: "command src not found",
+		},
+	}
+
+	// catch stdout
+	backupStdout := os.Stdout
+	defer func() {
+		os.Stdout = backupStdout
+	}()
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() { _ = w.Close() }()
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			var called interface{}
+			newCommand := func(name string, config interface{}) *Command {
+				return &Command{
+					Name:                  name,
+					Config:                config,
+					DefaultPointersConfig: reflect.New(reflect.TypeOf(config).Elem()).Interface(),
+					Run: func() error {
+						called = config
+						return nil
+					},
+				}
+			}
+
+			flaeg := New(newCommand("flaegtest", &VersionConfig{}), test.args)
+			flaeg.AddCommand(newCommand("copy", &CopyConfig{}))
+			flaeg.AddCommand(newCommand("move", &MoveConfig{}))
+			flaeg.AddCommand(newCommand("version", &VersionConfig{}))
+			flaeg.AddParser(reflect.TypeOf([]string{}), &parse.SliceStrings{})
+
+			err := flaeg.Run()
+			if len(test.expectedErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Errorf("Expected error %q got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(called, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, called)
+			}
+		})
+	}
+}
+
+func TestLoadWithCommandArgsTypedErrors(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		args  []string
+		check func(err error) bool
+	}{
+		{
+			desc: "missing argument",
+			args: []string{"src"},
+			check: func(err error) bool {
+				var missingErr *MissingArgumentError
+				return errors.As(err, &missingErr) && missingErr.Argument == "<count>" && missingErr.Field == "Count"
+			},
+		},
+		{
+			desc: "invalid argument",
+			args: []string{"src", "three"},
+			check: func(err error) bool {
+				var invalidErr *InvalidArgumentError
+				return errors.As(err, &invalidErr) && invalidErr.Argument == "<count>" && invalidErr.Field == "Count" && invalidErr.Value == "three"
+			},
+		},
+		{
+			desc: "too many arguments",
+			args: []string{"src", "3", "4", "5"},
+			check: func(err error) bool {
+				var tooManyErr *TooManyArgumentsError
+				return errors.As(err, &tooManyErr) && reflect.DeepEqual(tooManyErr.Args, []string{"4", "5"})
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "move",
+				Config:                &MoveConfig{},
+				DefaultPointersConfig: &MoveConfig{},
+			}
+
+			if err := LoadWithCommand(cmd, test.args, nil, nil, WithSilent()); !test.check(err) {
+				t.Errorf("Unexpected error %#v", err)
+			}
+		})
+	}
+}
+
+func TestGetArgFieldsErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   interface{}
+		expected string
+	}{
+		{
+			desc: "missing position",
+			config: &struct {
+				A string `arg:"0"`
+				B string `arg:"2"`
+			}{},
+			expected: `missing field tagged arg:"1"`,
+		},
+		{
+			desc: "invalid position",
+			config: &struct {
+				A string `arg:"first"`
+			}{},
+			expected: `invalid tag arg:"first" on field A`,
+		},
+		{
+			desc: "two rest fields",
+			config: &struct {
+				A []string `arg:"rest"`
+				B []string `arg:"rest"`
+			}{},
+			expected: `fields A and B are both tagged arg:"rest"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := getArgFields(test.config); err == nil || err.Error() != test.expected {
+				t.Errorf("Expected error %q got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestPrintHelpWithCommandArgs(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &VersionConfig{},
+		DefaultPointersConfig: &VersionConfig{},
+	}
+	copyCmd := &Command{
+		Name:                  "copy",
+		Config:                &CopyConfig{},
+		DefaultPointersConfig: &CopyConfig{},
+	}
+	rootCmd.AddCommand(copyCmd)
+
+	flagMap := make(map[string]reflect.StructField)
+	if err := getTypesRecursive(reflect.ValueOf(copyCmd.Config), flagMap, ""); err != nil {
+		t.Fatal(err)
+	}
+	defaultValMap := make(map[string]reflect.Value)
+	if err := getDefaultValue(reflect.ValueOf(copyCmd.Config), reflect.ValueOf(copyCmd.DefaultPointersConfig), defaultValMap, ""); err != nil {
+		t.Fatal(err)
+	}
+	parsers, err := parse.LoadParsers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// catch stdout
+	backupStdout := os.Stdout
+	defer func() {
+		os.Stdout = backupStdout
+	}()
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	if err := PrintHelpWithCommand(flagMap, defaultValMap, parsers, copyCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// read and restore stdout
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = backupStdout
+
+	output := string(out)
+	for _, check := range []string{"Usage: flaegtest copy [flags] <src> <dst...>", "<src>", "Source file", "Destination files", "--force"} {
+		if !strings.Contains(output, check) {
+			t.Errorf("Expected %q in help:\n%s", check, output)
+		}
+	}
+	if strings.Contains(output, "--src") || strings.Contains(output, "--dst") {
+		t.Errorf("Positional arguments must not be flags:\n%s", output)
+	}
+	if strings.Contains(output, "<command> --help") {
+		t.Errorf("Commands without sub-commands must not print the help on sub-commands:\n%s", output)
+	}
+}
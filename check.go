@@ -0,0 +1,174 @@
+package flaeg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// longTagPattern matches the valid values of the tag `long`
+var longTagPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// SchemaError is a mistake in the definition of a field of a config
+type SchemaError struct {
+	// Field is the path of the Go field, e.g. Owner.DateOfBirth
+	Field string
+	Err   error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+// Unwrap returns the error on the field
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// CheckError lists the mistakes found by Check
+type CheckError struct {
+	Errors []*SchemaError
+}
+
+func (e *CheckError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, schemaError := range e.Errors {
+		msgs = append(msgs, "\t"+schemaError.Error())
+	}
+	return "invalid config definition:\n" + strings.Join(msgs, "\n")
+}
+
+// Check walks the config of cmd, and the configs of its parents with persistent flags, and returns a CheckError listing:
+// - the short flags used by several fields
+// - the tags `short` longer than one character
+// - the flags used by several fields, once in lower case
+// - the unexported fields with tags
+// - the tags `long` with invalid characters
+// - the struct fields without description, whose fields have descriptions: they are not flags
+// It returns nil if no mistake is found.
+func Check(cmd *Command) error {
+	checker := &schemaChecker{
+		flags:  make(map[string]string),
+		shorts: make(map[string]string),
+	}
+	for _, command := range append([]*Command{cmd}, cmd.persistentCommands()...) {
+		checker.checkType(reflect.TypeOf(command.Config), "", "")
+	}
+
+	if len(checker.errors) > 0 {
+		return &CheckError{Errors: checker.errors}
+	}
+	return nil
+}
+
+// schemaChecker records the mistakes found while walking configs
+type schemaChecker struct {
+	// flags and shorts link the flags and the short flags already seen with the paths of their fields
+	flags  map[string]string
+	shorts map[string]string
+	errors []*SchemaError
+}
+
+func (c *schemaChecker) addError(path string, format string, args ...interface{}) {
+	c.errors = append(c.errors, &SchemaError{Field: path, Err: fmt.Errorf(format, args...)})
+}
+
+// checkType checks the fields of the struct typ, flagged key, whose Go path is path
+func (c *schemaChecker) checkType(typ reflect.Type, key string, path string) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldPath := field.Name
+		if len(path) > 0 {
+			fieldPath = path + "." + field.Name
+		}
+
+		if field.Anonymous {
+			// promoted fields are accessible without the embedded field name
+			c.checkType(field.Type, key, path)
+			continue
+		}
+
+		if !isExported(field.Name) || !isFlagField(field) {
+			c.checkNotFlag(field, fieldPath)
+			continue
+		}
+
+		name := c.checkFlag(field, key, fieldPath)
+		c.checkType(field.Type, name, fieldPath)
+	}
+}
+
+// checkNotFlag checks that the field, whose Go path is fieldPath, is not meant to be flagged:
+// an unexported field has no flag tags, and a struct field without description has no flagged fields
+func (c *schemaChecker) checkNotFlag(field reflect.StructField, fieldPath string) {
+	if !isExported(field.Name) {
+		if hasFlagTag(field) {
+			c.addError(fieldPath, "unexported field has flag tags")
+		}
+		return
+	}
+
+	if _, isArg := field.Tag.Lookup("arg"); !isArg && hasFlaggedFields(field.Type) {
+		c.addError(fieldPath, "struct field has no description: its fields are not flags")
+	}
+}
+
+// checkFlag checks the tags and the flag of the flagged field, whose Go path is fieldPath, and returns the flag
+func (c *schemaChecker) checkFlag(field reflect.StructField, key string, fieldPath string) string {
+	if long := field.Tag.Get("long"); len(long) > 0 && !longTagPattern.MatchString(long) {
+		c.addError(fieldPath, "long flag %q has invalid characters", long)
+	}
+
+	name := flagName(key, field)
+	if other, ok := c.flags[name]; ok {
+		c.addError(fieldPath, "flag --%s is already used by %s", name, other)
+	} else {
+		c.flags[name] = fieldPath
+	}
+
+	if short, ok := field.Tag.Lookup("short"); ok {
+		if len(short) != 1 {
+			c.addError(fieldPath, "short flag %q must be one character", short)
+		} else if other, ok := c.shorts[short]; ok {
+			c.addError(fieldPath, "short flag -%s is already used by %s", short, other)
+		} else {
+			c.shorts[short] = fieldPath
+		}
+	}
+	return name
+}
+
+// hasFlagTag returns true if the field has one of the tags of the flags
+func hasFlagTag(field reflect.StructField) bool {
+	for _, tag := range []string{"description", "long", "short", "arg"} {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlaggedFields returns true if typ is a struct, or a pointer on a struct, with flagged fields
+func hasFlaggedFields(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.Anonymous && hasFlaggedFields(field.Type) || isFlagField(field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package flaeg
+
+import "testing"
+
+type MisdefinedConfig struct {
+	LogLevel string            `short:"l" description:"Log level"`
+	Listen   string            `short:"l" description:"Listen address"`
+	Timeout  string            `short:"to" description:"Timeout"`
+	Name     string            `description:"Name"`
+	Alias    string            `long:"NAME" description:"Alias"`
+	Address  string            `long:"addr.ess" description:"Address"`
+	secret   string            `description:"Secret"`
+	Server   *MisdefinedServer // no description
+	Backend  *MisdefinedServer `description:"Enable backend"`
+}
+
+type MisdefinedServer struct {
+	Port int `short:"p" description:"Port"`
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   interface{}
+		expected []*SchemaError
+	}{
+		{
+			desc:   "valid config",
+			config: newConfiguration(),
+		},
+		{
+			desc:   "every mistake",
+			config: &MisdefinedConfig{},
+			expected: []*SchemaError{
+				{Field: "Listen", Err: errorString(`short flag -l is already used by LogLevel`)},
+				{Field: "Timeout", Err: errorString(`short flag "to" must be one character`)},
+				{Field: "Alias", Err: errorString(`flag --name is already used by Name`)},
+				{Field: "Address", Err: errorString(`long flag "addr.ess" has invalid characters`)},
+				{Field: "secret", Err: errorString(`unexported field has flag tags`)},
+				{Field: "Server", Err: errorString(`struct field has no description: its fields are not flags`)},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := Check(&Command{Name: "flaegtest", Config: test.config})
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error got %v", err)
+				}
+				return
+			}
+
+			checkErr, ok := err.(*CheckError)
+			if !ok {
+				t.Fatalf("Expected a CheckError got %v", err)
+			}
+			if len(checkErr.Errors) != len(test.expected) {
+				t.Fatalf("Expected %d errors got %v", len(test.expected), err)
+			}
+			for i, schemaErr := range checkErr.Errors {
+				if schemaErr.Error() != test.expected[i].Error() {
+					t.Errorf("Expected %q got %q", test.expected[i], schemaErr)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPersistentFlags(t *testing.T) {
+	rootCmd := &Command{
+		Name:            "flaegtest",
+		Config:          &MisdefinedServer{},
+		PersistentFlags: true,
+	}
+	subCmd := &Command{
+		Name:   "serve",
+		Config: &MisdefinedServer{},
+	}
+	rootCmd.AddCommand(subCmd)
+
+	expected := &CheckError{Errors: []*SchemaError{
+		{Field: "Port", Err: errorString("flag --port is already used by Port")},
+		{Field: "Port", Err: errorString("short flag -p is already used by Port")},
+	}}
+	if err := Check(subCmd); err == nil || err.Error() != expected.Error() {
+		t.Errorf("\nexpected \t%v \ngot \t\t%v\n", expected, err)
+	}
+}
+
+// errorString is a comparable error
+type errorString string
+
+func (e errorString) Error() string {
+	return string(e)
+}
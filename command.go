@@ -0,0 +1,118 @@
+package flaeg
+
+import (
+	"strings"
+)
+
+// AddCommand adds a sub-command to the command.
+// Sub-commands can be nested at any depth: `app cluster node add`
+func (c *Command) AddCommand(command *Command) {
+	command.parent = c
+	c.subCommands = append(c.subCommands, command)
+}
+
+// SubCommands returns the direct sub-commands of the command
+func (c *Command) SubCommands() []*Command {
+	return c.subCommands
+}
+
+// Parent returns the parent command, nil for a root command
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Path returns the names of the command and of its parents from the root command, separated by spaces
+func (c *Command) Path() string {
+	names := []string{c.Name}
+	for parent := c.parent; parent != nil; parent = parent.parent {
+		names = append([]string{parent.Name}, names...)
+	}
+	return strings.Join(names, " ")
+}
+
+// hasArgs returns true if the command receives positional arguments:
+// its config has positional fields, or it runs with RunWithContext and has no sub-commands
+func (c *Command) hasArgs() bool {
+	if c.RunWithContext != nil && len(c.subCommands) == 0 {
+		return true
+	}
+	argFields, err := getArgFields(c.Config)
+	return err == nil && len(argFields) > 0
+}
+
+// findSubCommand returns the direct sub-command called name, or with an alias name, nil if it does not exist.
+// With prefixMatching, a sub-command which is not hidden can be called with a prefix of its name or of its aliases,
+// if the prefix is unambiguous.
+func (c *Command) findSubCommand(name string, prefixMatching bool) (*Command, error) {
+	for _, command := range c.subCommands {
+		if command.hasName(name) {
+			return command, nil
+		}
+	}
+	if !prefixMatching {
+		return nil, nil
+	}
+
+	var candidates []*Command
+	for _, command := range c.subCommands {
+		if !command.HideHelp && command.hasNamePrefix(name) {
+			candidates = append(candidates, command)
+		}
+	}
+	if len(candidates) > 1 {
+		names := make([]string, 0, len(candidates))
+		for _, command := range candidates {
+			names = append(names, command.Name)
+		}
+		return nil, &AmbiguousCommandError{Command: name, Candidates: names}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return nil, nil
+}
+
+// hasName returns true if name is the name or an alias of the command
+func (c *Command) hasName(name string) bool {
+	if c.Name == name {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNamePrefix returns true if prefix is a prefix of the name or of an alias of the command
+func (c *Command) hasNamePrefix(prefix string) bool {
+	if strings.HasPrefix(c.Name, prefix) {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if strings.HasPrefix(alias, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// helpName returns the name of the command followed by its aliases
+func (c *Command) helpName() string {
+	return strings.Join(append([]string{c.Name}, c.Aliases...), ", ")
+}
+
+// helpSubCommands returns the sub-commands listed in the help of cmd: its direct sub-commands,
+// and the commands of subCmd if cmd is the first one (see PrintHelpWithCommand)
+func helpSubCommands(cmd *Command, subCmd []*Command) []*Command {
+	commands := append([]*Command{}, cmd.subCommands...)
+	if len(subCmd) > 1 && cmd == subCmd[0] {
+		for _, command := range subCmd[1:] {
+			if command.parent != cmd {
+				commands = append(commands, command)
+			}
+		}
+	}
+	return commands
+}
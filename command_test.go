@@ -0,0 +1,268 @@
+package flaeg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newCommandTree returns the root command of `flaegtest cluster node add` and `flaegtest cluster node remove`
+// Every command records its name in called when it runs.
+func newCommandTree(called *[]string) *Command {
+	newCommand := func(name string) *Command {
+		config := &VersionConfig{Version: "0.1"}
+		return &Command{
+			Name:                  name,
+			Description:           "The " + name + " command",
+			Config:                config,
+			DefaultPointersConfig: &VersionConfig{},
+			Run: func() error {
+				*called = append(*called, name+" "+config.Version)
+				return nil
+			},
+		}
+	}
+
+	add := newCommand("add")
+	add.Aliases = []string{"new"}
+	node := newCommand("node")
+	node.AddCommand(add)
+	node.AddCommand(newCommand("remove"))
+	cluster := newCommand("cluster")
+	cluster.AddCommand(node)
+	root := newCommand("flaegtest")
+	root.AddCommand(cluster)
+	return root
+}
+
+func TestFlaegRunSubCommandTree(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected string
+		path     string
+	}{
+		{
+			desc:     "root",
+			args:     []string{"-v1.0"},
+			expected: "flaegtest 1.0",
+			path:     "flaegtest",
+		},
+		{
+			desc:     "first level",
+			args:     []string{"cluster"},
+			expected: "cluster 0.1",
+			path:     "flaegtest cluster",
+		},
+		{
+			desc:     "last level",
+			args:     []string{"cluster", "node", "add", "--version=2.0"},
+			expected: "add 2.0",
+			path:     "flaegtest cluster node add",
+		},
+		{
+			desc:     "upper case",
+			args:     []string{"Cluster", "Node", "Remove"},
+			expected: "remove 0.1",
+			path:     "flaegtest cluster node remove",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var called []string
+			flaeg := New(newCommandTree(&called), test.args)
+
+			if err := flaeg.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(called) != 1 || called[0] != test.expected {
+				t.Errorf("Expected %q to run got %q", test.expected, called)
+			}
+
+			cmd, err := flaeg.GetCommand()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Path() != test.path {
+				t.Errorf("Expected path %q got %q", test.path, cmd.Path())
+			}
+		})
+	}
+}
+
+func TestFlaegRunSubCommandTreeUnknownCommand(t *testing.T) {
+	var called []string
+	flaeg := New(newCommandTree(&called), []string{"cluster", "add"})
+
+	check := "command add not found"
+	if err := flaeg.Run(); err == nil || err.Error() != check {
+		t.Errorf("Expected error %q got %v", check, err)
+	}
+	if len(called) > 0 {
+		t.Errorf("No command must run, got %q", called)
+	}
+}
+
+func TestFlaegRunSubCommandTreeHelp(t *testing.T) {
+	var called []string
+	flaeg := New(newCommandTree(&called), []string{"cluster", "node", "--help"})
+
+	// catch stdout
+	backupStdout := os.Stdout
+	defer func() {
+		os.Stdout = backupStdout
+	}()
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_ = flaeg.Run()
+
+	// read and restore stdout
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = backupStdout
+
+	output := string(out)
+	for _, check := range []string{"Usage: flaegtest cluster node [flags]", "add, new", "The add command", "The remove command"} {
+		if !strings.Contains(output, check) {
+			t.Errorf("Expected %q in help:\n%s", check, output)
+		}
+	}
+	if strings.Contains(output, "The cluster command") {
+		t.Errorf("Only the direct sub-commands must be listed:\n%s", output)
+	}
+}
+
+func TestFlaegRunSubCommandAliases(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		args           []string
+		prefixMatching bool
+		expected       string
+		expectedErr    string
+	}{
+		{
+			desc:     "alias",
+			args:     []string{"ver"},
+			expected: "version",
+		},
+		{
+			desc:        "prefix without prefix matching",
+			args:        []string{"vers"},
+			expectedErr: "command vers not found, did you mean ver?",
+		},
+		{
+			desc:           "unambiguous prefix",
+			args:           []string{"vers"},
+			prefixMatching: true,
+			expected:       "version",
+		},
+		{
+			desc:           "prefix of an alias",
+			args:           []string{"che"},
+			prefixMatching: true,
+			expected:       "verify",
+		},
+		{
+			desc:           "exact name before prefixes",
+			args:           []string{"ver"},
+			prefixMatching: true,
+			expected:       "version",
+		},
+		{
+			desc:           "ambiguous prefix",
+			args:           []string{"ve"},
+			prefixMatching: true,
+			expectedErr:    "command ve is ambiguous: version, verify",
+		},
+		{
+			desc:           "hidden command",
+			args:           []string{"sec"},
+			prefixMatching: true,
+			expectedErr:    "command sec not found",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var called string
+			newCommand := func(name string, aliases ...string) *Command {
+				return &Command{
+					Name:                  name,
+					Aliases:               aliases,
+					Config:                &VersionConfig{},
+					DefaultPointersConfig: &VersionConfig{},
+					Run: func() error {
+						called = name
+						return nil
+					},
+				}
+			}
+
+			secretCmd := newCommand("secret")
+			secretCmd.HideHelp = true
+
+			flaeg := New(newCommand("flaegtest"), test.args)
+			flaeg.AddCommand(newCommand("version", "ver", "v"))
+			flaeg.AddCommand(newCommand("verify", "check"))
+			flaeg.AddCommand(secretCmd)
+			if test.prefixMatching {
+				flaeg.EnablePrefixMatching()
+			}
+
+			err := flaeg.Run()
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Errorf("Expected error %q got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if called != test.expected {
+				t.Errorf("Expected %q to run got %q", test.expected, called)
+			}
+		})
+	}
+}
+
+func TestFlaegRunAmbiguousCommandError(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &VersionConfig{},
+		DefaultPointersConfig: &VersionConfig{},
+	}
+	for _, name := range []string{"version", "verify"} {
+		rootCmd.AddCommand(&Command{
+			Name:                  name,
+			Config:                &VersionConfig{},
+			DefaultPointersConfig: &VersionConfig{},
+			Run:                   func() error { return nil },
+		})
+	}
+
+	flaeg := New(rootCmd, []string{"ver"})
+	flaeg.EnablePrefixMatching()
+
+	var ambiguousErr *AmbiguousCommandError
+	if err := flaeg.Run(); !errors.As(err, &ambiguousErr) || ambiguousErr.Command != "ver" || !reflect.DeepEqual(ambiguousErr.Candidates, []string{"version", "verify"}) {
+		t.Errorf("Expected an AmbiguousCommandError on ver got %v", err)
+	}
+}
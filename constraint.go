@@ -0,0 +1,214 @@
+package flaeg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// constraintTags are the tags constraining the value of a field, in the order they are checked and displayed
+var constraintTags = []string{"min", "max", "minlen", "maxlen", "pattern", "oneof"}
+
+// checkConstraints checks the value of a field against its constraint tags :
+// - min and max are bounds parsed by the parser of the field, the value returned by Get() must be ordered
+// - minlen and maxlen bound the length of a string or the number of elements of a slice
+// - pattern is a regular expression which must match the value, or every element of a []string
+// - oneof is a comma-separated list of the allowed values, or of the allowed elements of a []string
+func checkConstraints(field reflect.StructField, fieldValue reflect.Value, parsers map[reflect.Type]parse.Parser) error {
+	if len(constraintsDescription(field)) == 0 {
+		return nil
+	}
+
+	parser, ok := findParser(parsers, field.Type)
+	if !ok {
+		return fmt.Errorf("unable to check constraints: %v", ErrParserNotFound)
+	}
+	value := cloneParser(parser)
+	value.SetValue(fieldValue.Interface())
+
+	for _, constraint := range constraintTags {
+		if tag, ok := field.Tag.Lookup(constraint); ok {
+			if err := constraintCheckers[constraint](value, parser, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// constraintCheckers link the constraint tags with the functions checking the value of a field,
+// given the parser of the field and the tag
+var constraintCheckers = map[string]func(value parse.Parser, parser parse.Parser, tag string) error{
+	"min":     checkMin,
+	"max":     checkMax,
+	"minlen":  checkMinLen,
+	"maxlen":  checkMaxLen,
+	"pattern": checkPattern,
+	"oneof":   checkOneOf,
+}
+
+func checkMin(value parse.Parser, parser parse.Parser, tag string) error {
+	cmp, err := compareBound(value, parser, "min", tag)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return fmt.Errorf("value %q is lower than min %q", value.String(), tag)
+	}
+	return nil
+}
+
+func checkMax(value parse.Parser, parser parse.Parser, tag string) error {
+	cmp, err := compareBound(value, parser, "max", tag)
+	if err != nil {
+		return err
+	}
+	if cmp > 0 {
+		return fmt.Errorf("value %q is greater than max %q", value.String(), tag)
+	}
+	return nil
+}
+
+func checkMinLen(value parse.Parser, _ parse.Parser, tag string) error {
+	length, bound, err := lengthBound(value, "minlen", tag)
+	if err != nil {
+		return err
+	}
+	if length < bound {
+		return fmt.Errorf("length %d is lower than minlen %d", length, bound)
+	}
+	return nil
+}
+
+func checkMaxLen(value parse.Parser, _ parse.Parser, tag string) error {
+	length, bound, err := lengthBound(value, "maxlen", tag)
+	if err != nil {
+		return err
+	}
+	if length > bound {
+		return fmt.Errorf("length %d is greater than maxlen %d", length, bound)
+	}
+	return nil
+}
+
+func checkPattern(value parse.Parser, _ parse.Parser, tag string) error {
+	pattern, err := regexp.Compile(tag)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", tag, err)
+	}
+	for _, elem := range valueElements(value) {
+		if !pattern.MatchString(elem) {
+			return fmt.Errorf("value %q does not match pattern %q", elem, tag)
+		}
+	}
+	return nil
+}
+
+func checkOneOf(value parse.Parser, _ parse.Parser, tag string) error {
+	allowed := make(map[string]bool)
+	for _, option := range strings.Split(tag, ",") {
+		allowed[strings.TrimSpace(option)] = true
+	}
+	for _, elem := range valueElements(value) {
+		if !allowed[elem] {
+			return fmt.Errorf("value %q is not one of %q", elem, tag)
+		}
+	}
+	return nil
+}
+
+// compareBound parses the bound tag of a constraint with parser and compares value with it
+func compareBound(value parse.Parser, parser parse.Parser, constraint string, tag string) (int, error) {
+	bound := cloneParser(parser)
+	if err := bound.Set(tag); err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", constraint, tag, err)
+	}
+
+	cmp, ok := compareValues(value.Get(), bound.Get())
+	if !ok {
+		return 0, fmt.Errorf("%s is not supported by type %T", constraint, value.Get())
+	}
+	return cmp, nil
+}
+
+// compareValues returns -1, 0 or +1 if a is lower, equal or greater than b.
+// It returns false if the values are not ordered, or not of the same kind.
+func compareValues(a interface{}, b interface{}) (int, bool) {
+	valueA, valueB := reflect.ValueOf(a), reflect.ValueOf(b)
+	if valueA.Kind() != valueB.Kind() {
+		return 0, false
+	}
+
+	switch valueA.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compare(valueA.Int() < valueB.Int(), valueA.Int() > valueB.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compare(valueA.Uint() < valueB.Uint(), valueA.Uint() > valueB.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return compare(valueA.Float() < valueB.Float(), valueA.Float() > valueB.Float()), true
+	case reflect.String:
+		return compare(valueA.String() < valueB.String(), valueA.String() > valueB.String()), true
+	default:
+		return 0, false
+	}
+}
+
+func compare(lower bool, greater bool) int {
+	switch {
+	case lower:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// lengthBound returns the length of value and the bound tag of a length constraint
+func lengthBound(value parse.Parser, constraint string, tag string) (int, int, error) {
+	bound, err := strconv.Atoi(tag)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s %q: %v", constraint, tag, err)
+	}
+
+	val := reflect.ValueOf(value.Get())
+	switch val.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(val.String()), bound, nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return val.Len(), bound, nil
+	default:
+		return 0, 0, fmt.Errorf("%s is not supported by type %T", constraint, value.Get())
+	}
+}
+
+// valueElements returns the elements of a []string value, or the value as string
+func valueElements(value parse.Parser) []string {
+	switch val := value.Get().(type) {
+	case string:
+		return []string{val}
+	case []string:
+		return val
+	default:
+		return []string{value.String()}
+	}
+}
+
+// constraintsDescription returns the constraints of a field for the help, empty if the field has no constraint
+func constraintsDescription(field reflect.StructField) string {
+	var constraints []string
+	for _, constraint := range constraintTags {
+		if tag, ok := field.Tag.Lookup(constraint); ok {
+			constraints = append(constraints, fmt.Sprintf("%s %q", constraint, tag))
+		}
+	}
+	if len(constraints) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(constraints, ", ") + ")"
+}
@@ -0,0 +1,189 @@
+package flaeg
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg/parse"
+)
+
+type ConstraintConfig struct {
+	Port     int            `min:"1" max:"65535" description:"Port"`
+	Rate     float64        `min:"0" max:"1" description:"Rate"`
+	Timeout  parse.Duration `min:"1s" max:"1m" description:"Timeout"`
+	Name     string         `minlen:"2" maxlen:"5" pattern:"^[a-z]+$" description:"Name"`
+	LogLevel string         `oneof:"DEBUG,INFO,WARN" description:"Log level"`
+	Tags     []string       `maxlen:"2" oneof:"a,b,c" description:"Tags"`
+}
+
+func newConstraintConfig() *ConstraintConfig {
+	return &ConstraintConfig{
+		Port:     80,
+		Timeout:  parse.Duration(time.Second),
+		LogLevel: "INFO",
+	}
+}
+
+func TestLoadWithCommandConstraints(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected []string
+	}{
+		{
+			desc: "valid values",
+			args: []string{"--port=8080", "--rate=0.5", "--timeout=30s", "--name=foo", "--loglevel=WARN", "--tags=a,c"},
+		},
+		{
+			desc: "zero values not set",
+		},
+		{
+			desc: "zero value set",
+			args: []string{"--port=0"},
+			expected: []string{
+				`--port: value "0" is lower than min "1"`,
+			},
+		},
+		{
+			desc: "out of range",
+			args: []string{"--port=70000", "--rate=1.5", "--timeout=2m"},
+			expected: []string{
+				`--port: value "70000" is greater than max "65535"`,
+				`--rate: value "1.5" is greater than max "1"`,
+				`--timeout: value "2m0s" is greater than max "1m"`,
+			},
+		},
+		{
+			desc: "invalid strings",
+			args: []string{"--name=f", "--loglevel=TRACE"},
+			expected: []string{
+				`--loglevel: value "TRACE" is not one of "DEBUG,INFO,WARN"`,
+				`--name: length 1 is lower than minlen 2`,
+			},
+		},
+		{
+			desc: "pattern",
+			args: []string{"--name=Foo"},
+			expected: []string{
+				`--name: value "Foo" does not match pattern "^[a-z]+$"`,
+			},
+		},
+		{
+			desc: "invalid slice",
+			args: []string{"--tags=a,b,c"},
+			expected: []string{
+				`--tags: length 3 is greater than maxlen 2`,
+			},
+		},
+		{
+			desc: "invalid slice element",
+			args: []string{"--tags=a,d"},
+			expected: []string{
+				`--tags: value "d" is not one of "a,b,c"`,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                newConstraintConfig(),
+				DefaultPointersConfig: newConstraintConfig(),
+			}
+			customParsers := map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]string{}): &parse.SliceStrings{},
+			}
+
+			err := LoadWithCommand(cmd, test.args, customParsers, nil)
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			validationError, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Expected a ValidationError got %v", err)
+			}
+			var errs []string
+			for _, fieldError := range validationError.Errors {
+				errs = append(errs, fieldError.Error())
+			}
+			if !reflect.DeepEqual(errs, test.expected) {
+				t.Errorf("\nexpected \t%q \ngot \t\t%q\n", test.expected, errs)
+			}
+		})
+	}
+}
+
+func TestCheckConstraintsInvalidTags(t *testing.T) {
+	type invalidConfig struct {
+		Port    int       `min:"one" description:"Port"`
+		Date    time.Time `min:"2016-04-20T17:39:00Z" description:"Date"`
+		Enabled bool      `minlen:"1" description:"Enabled"`
+		Name    string    `pattern:"[a-" description:"Name"`
+	}
+
+	config := &invalidConfig{Port: 1, Date: time.Now(), Enabled: true, Name: "foo"}
+	parsers, err := parse.LoadParsers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldErrors := checkFields(reflect.ValueOf(config), parsers, nil, nil)
+
+	check := []string{
+		`--port: invalid min "one"`,
+		`--date: min is not supported by type time.Time`,
+		`--enabled: minlen is not supported by type bool`,
+		`--name: invalid pattern "[a-"`,
+	}
+	if len(fieldErrors) != len(check) {
+		t.Fatalf("Expected %d errors got %v", len(check), fieldErrors)
+	}
+	for i, fieldError := range fieldErrors {
+		if !strings.HasPrefix(fieldError.Error(), check[i]) {
+			t.Errorf("Expected error %q got %q", check[i], fieldError.Error())
+		}
+	}
+}
+
+func TestPrintHelpConstraints(t *testing.T) {
+	config := newConstraintConfig()
+	flagMap := make(map[string]reflect.StructField)
+	if err := getTypesRecursive(reflect.ValueOf(config), flagMap, ""); err != nil {
+		t.Fatal(err)
+	}
+	defaultValMap := make(map[string]reflect.Value)
+	if err := getDefaultValue(reflect.ValueOf(config), reflect.ValueOf(newConstraintConfig()), defaultValMap, ""); err != nil {
+		t.Fatal(err)
+	}
+	parsers, err := parse.LoadParsers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := printFlagsDescriptionsDefaultValues(flagMap, defaultValMap, parsers, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLines := []string{
+		`(default "80") (min "1", max "65535")`,
+		`(default "INFO") (oneof "DEBUG,INFO,WARN")`,
+		`(minlen "2", maxlen "5", pattern "^[a-z]+$")`,
+	}
+	for _, line := range checkLines {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in help:\n%s", line, out.String())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package flaeg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+)
+
+// EnvSource is a Source of flags values read from environment variables.
+// The variable name is built from the flag name and the prefix:
+// the flag --db.comax with the prefix MYAPP is read from MYAPP_DB_COMAX.
+// The StructTag `env` overwrites the variable name of a field.
+type EnvSource struct {
+	Prefix string
+	// LookupEnv retrieves the value of an environment variable, os.LookupEnv if nil
+	LookupEnv func(key string) (string, bool)
+}
+
+// NewEnvSource creates an EnvSource reading the process environment
+func NewEnvSource(prefix string) *EnvSource {
+	return &EnvSource{
+		Prefix:    prefix,
+		LookupEnv: os.LookupEnv,
+	}
+}
+
+// EnvName returns the name of the environment variable bound to the flag flg
+func (e *EnvSource) EnvName(flg string, field reflect.StructField) string {
+	if tag := field.Tag.Get("env"); len(tag) > 0 {
+		return tag
+	}
+
+	name := strings.ToUpper(strings.Replace(flg, ".", "_", -1))
+	if len(e.Prefix) == 0 {
+		return name
+	}
+	return strings.ToUpper(e.Prefix) + "_" + name
+}
+
+// Name returns the name of the EnvSource
+func (e *EnvSource) Name() string {
+	return "env"
+}
+
+// Values returns the values of the flags set in the environment
+func (e *EnvSource) Values(flagMap map[string]reflect.StructField) (map[string]interface{}, error) {
+	lookupEnv := e.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	values := make(map[string]interface{})
+	for flg, structField := range flagMap {
+		if value, ok := lookupEnv(e.EnvName(flg, structField)); ok {
+			values[flg] = value
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,166 @@
+package flaeg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg/parse"
+)
+
+func lookupEnvMap(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	config := &struct {
+		LogLevel string `description:"Log level"`
+		Token    string `env:"API_TOKEN" description:"API token"`
+	}{}
+
+	flagMap := make(map[string]reflect.StructField)
+	if err := getTypesRecursive(reflect.ValueOf(config), flagMap, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		desc     string
+		prefix   string
+		flag     string
+		field    reflect.StructField
+		expected string
+	}{
+		{
+			desc:     "no prefix",
+			flag:     "db.comax",
+			expected: "DB_COMAX",
+		},
+		{
+			desc:     "with prefix",
+			prefix:   "myapp",
+			flag:     "db.comax",
+			expected: "MYAPP_DB_COMAX",
+		},
+		{
+			desc:     "field without env tag",
+			prefix:   "MYAPP",
+			flag:     "loglevel",
+			field:    flagMap["loglevel"],
+			expected: "MYAPP_LOGLEVEL",
+		},
+		{
+			desc:     "env tag",
+			prefix:   "MYAPP",
+			flag:     "token",
+			field:    flagMap["token"],
+			expected: "API_TOKEN",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			envName := NewEnvSource(test.prefix).EnvName(test.flag, test.field)
+			if envName != test.expected {
+				t.Errorf("Got %s expected %s", envName, test.expected)
+			}
+		})
+	}
+}
+
+func TestLoadWithCommandEnv(t *testing.T) {
+	config := newConfiguration()
+	defaultPointers := newDefaultPointersConfiguration()
+
+	cmd := &Command{
+		Name:                  "flaegtest",
+		Config:                config,
+		DefaultPointersConfig: defaultPointers,
+	}
+
+	env := &EnvSource{
+		Prefix: "MYAPP",
+		LookupEnv: lookupEnvMap(map[string]string{
+			"MYAPP_LOGLEVEL": "WARN",
+			"MYAPP_TIMEOUT":  "3s",
+			"MYAPP_DB_COMAX": "5",
+		}),
+	}
+
+	args := []string{
+		"--loglevel=INFO",
+	}
+
+	customParsers := map[reflect.Type]parse.Parser{
+		reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+	}
+
+	if err := LoadWithCommand(cmd, args, customParsers, nil, WithEnv(env)); err != nil {
+		t.Fatal(err)
+	}
+
+	check := newConfiguration()
+	check.LogLevel = "INFO"
+	check.Timeout = parse.Duration(3 * time.Second)
+	check.Db = newDefaultPointersConfiguration().Db
+	check.Db.ConnectionMax = 5
+
+	if !reflect.DeepEqual(config, check) {
+		t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", check, config)
+	}
+}
+
+func TestLoadWithCommandEnvInvalidValue(t *testing.T) {
+	cmd := &Command{
+		Name:                  "flaegtest",
+		Config:                newConfiguration(),
+		DefaultPointersConfig: newDefaultPointersConfiguration(),
+	}
+
+	env := &EnvSource{
+		LookupEnv: lookupEnvMap(map[string]string{
+			"DB_LOAD": "many",
+		}),
+	}
+
+	customParsers := map[reflect.Type]parse.Parser{
+		reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+	}
+
+	err := LoadWithCommand(cmd, nil, customParsers, nil, WithEnv(env))
+	if err == nil || !strings.Contains(err.Error(), "db.load") {
+		t.Errorf("Expected error on db.load got %v", err)
+	}
+}
+
+func TestFlaegSetEnv(t *testing.T) {
+	versionConfig := &VersionConfig{Version: "0.1"}
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                versionConfig,
+		DefaultPointersConfig: &VersionConfig{},
+		Run: func() error {
+			return nil
+		},
+	}
+
+	flaeg := New(rootCmd, nil)
+	flaeg.SetEnv(&EnvSource{
+		Prefix:    "FLAEGTEST",
+		LookupEnv: lookupEnvMap(map[string]string{"FLAEGTEST_VERSION": "2.0"}),
+	})
+
+	if err := flaeg.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if versionConfig.Version != "2.0" {
+		t.Errorf("Expected version 2.0 got %s", versionConfig.Version)
+	}
+}
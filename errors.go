@@ -0,0 +1,242 @@
+package flaeg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnknownFlagError is returned when a flag given as argument, or by a source, is not defined
+type UnknownFlagError struct {
+	Flag string
+	// Suggestion is the closest defined flag, empty if no flag is close enough
+	Suggestion string
+	// Source is the name of the source giving the flag, or "flags group" for a group of flags, empty for an argument
+	Source string
+}
+
+func (e *UnknownFlagError) Error() string {
+	if len(e.Source) > 0 {
+		return fmt.Sprintf("unknown flag --%s in %s", e.Flag, e.Source)
+	}
+	if len(e.Suggestion) > 0 {
+		return fmt.Sprintf("unknown flag --%s, did you mean --%s?", e.Flag, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown flag --%s", e.Flag)
+}
+
+// InvalidValueError is returned when the parser of a flag fails to parse its value
+type InvalidValueError struct {
+	Flag string
+	// Field is the path of the Go field of the flag, e.g. Owner.DateOfBirth
+	Field string
+	Value string
+	// Source is the name of the source giving the value, empty for an argument
+	Source string
+	Err    error
+}
+
+func (e *InvalidValueError) Error() string {
+	if len(e.Source) > 0 {
+		return fmt.Sprintf("invalid value %q for --%s from %s: %v", e.Value, e.Flag, e.Source, e.Err)
+	}
+	return fmt.Sprintf("invalid argument %q for --%s: %v", e.Value, e.Flag, e.Err)
+}
+
+// Unwrap returns the error of the parser
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// ParserNotFoundError is returned when no parser matches the type of a flagged field
+type ParserNotFoundError struct {
+	Flag string
+	// Field is the path of the Go field of the flag, e.g. Owner.DateOfBirth
+	Field string
+	Type  reflect.Type
+}
+
+func (e *ParserNotFoundError) Error() string {
+	return fmt.Sprintf("parser not found for flag --%s of type %s", e.Flag, e.Type)
+}
+
+// Is makes errors.Is(err, ErrParserNotFound) true
+func (e *ParserNotFoundError) Is(target error) bool {
+	return target == ErrParserNotFound
+}
+
+// ParsersNotFoundError lists the flagged fields whose types have no parser
+type ParsersNotFoundError struct {
+	Errors []*ParserNotFoundError
+}
+
+func (e *ParsersNotFoundError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, "\t"+err.Error())
+	}
+	return "parsers not found:\n" + strings.Join(msgs, "\n")
+}
+
+// Is makes errors.Is(err, ErrParserNotFound) true
+func (e *ParsersNotFoundError) Is(target error) bool {
+	return target == ErrParserNotFound
+}
+
+// MissingDefaultPointerError is returned when the flag of a pointer field is called
+// and DefaultPointersConfig has no value for this field
+type MissingDefaultPointerError struct {
+	Flag string
+	// Field is the path of the Go field of the flag, e.g. Owner.DateOfBirth
+	Field string
+}
+
+func (e *MissingDefaultPointerError) Error() string {
+	return fmt.Sprintf("flag %s default value not provided", e.Flag)
+}
+
+// UnknownCommandError is returned when a sub-command given as argument is not defined
+type UnknownCommandError struct {
+	Command string
+	// Suggestion is the closest name or alias of a sub-command, empty if no one is close enough
+	Suggestion string
+}
+
+func (e *UnknownCommandError) Error() string {
+	if len(e.Suggestion) > 0 {
+		return fmt.Sprintf("command %s not found, did you mean %s?", e.Command, e.Suggestion)
+	}
+	return fmt.Sprintf("command %s not found", e.Command)
+}
+
+// AmbiguousCommandError is returned when a prefix given as argument matches several sub-commands
+type AmbiguousCommandError struct {
+	Command string
+	// Candidates are the names of the sub-commands matching the prefix
+	Candidates []string
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	return fmt.Sprintf("command %s is ambiguous: %s", e.Command, strings.Join(e.Candidates, ", "))
+}
+
+// DuplicateFlagError is returned when several fields, or a field and a built-in flag, have the same flag key
+type DuplicateFlagError struct {
+	Flag string
+	// Field is the path of the first Go field of the flag, e.g. Owner.DateOfBirth
+	Field string
+}
+
+func (e *DuplicateFlagError) Error() string {
+	return fmt.Sprintf("tag already exists: %s", e.Flag)
+}
+
+// MissingArgumentError is returned when a positional argument is missing
+type MissingArgumentError struct {
+	// Argument is the argument as shown in the usage line, e.g. <src>
+	Argument string
+	// Field is the path of the Go field of the argument
+	Field string
+}
+
+func (e *MissingArgumentError) Error() string {
+	return fmt.Sprintf("missing argument %s", e.Argument)
+}
+
+// InvalidArgumentError is returned when the parser of a positional argument fails to parse its value
+type InvalidArgumentError struct {
+	// Argument is the argument as shown in the usage line, e.g. <src>
+	Argument string
+	// Field is the path of the Go field of the argument
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *InvalidArgumentError) Error() string {
+	return fmt.Sprintf("invalid argument %q for %s: %v", e.Value, e.Argument, e.Err)
+}
+
+// Unwrap returns the error of the parser
+func (e *InvalidArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// TooManyArgumentsError is returned when positional arguments are left once every positional field is set
+type TooManyArgumentsError struct {
+	Args []string
+}
+
+func (e *TooManyArgumentsError) Error() string {
+	return fmt.Sprintf("too many arguments: %s", strings.Join(e.Args, " "))
+}
+
+// withFieldPath sets the path of the Go field of the flag in error, looked up in the config of cmd
+// and in the configs of its parents with persistent flags
+func withFieldPath(err error, cmd *Command) error {
+	var flg string
+	var field *string
+	switch e := err.(type) {
+	case *InvalidValueError:
+		flg, field = e.Flag, &e.Field
+	case *ParserNotFoundError:
+		flg, field = e.Flag, &e.Field
+	case *MissingDefaultPointerError:
+		flg, field = e.Flag, &e.Field
+	case *DuplicateFlagError:
+		flg, field = e.Flag, &e.Field
+	case *ParsersNotFoundError:
+		for _, parserErr := range e.Errors {
+			_ = withFieldPath(parserErr, cmd)
+		}
+		return err
+	case *ValidationError:
+		for _, fieldError := range e.Errors {
+			_ = withFieldPath(fieldError.Err, cmd)
+		}
+		return err
+	default:
+		return err
+	}
+
+	commands := append([]*Command{cmd}, cmd.persistentCommands()...)
+	for _, command := range commands {
+		if path := fieldPath(reflect.TypeOf(command.Config), "", flg); len(path) > 0 {
+			*field = path
+			break
+		}
+	}
+	return err
+}
+
+// fieldPath returns the path of the Go field of the flag flg in typ, e.g. Owner.DateOfBirth,
+// or an empty string if no field has this flag
+func fieldPath(typ reflect.Type, key string, flg string) string {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			// promoted fields are accessible without the embedded field name
+			if path := fieldPath(field.Type, key, flg); len(path) > 0 {
+				return path
+			}
+		} else if isFlagField(field) {
+			name := flagName(key, field)
+			if name == flg {
+				return field.Name
+			}
+			if strings.HasPrefix(flg, name+".") {
+				if path := fieldPath(field.Type, name, flg); len(path) > 0 {
+					return field.Name + "." + path
+				}
+			}
+		}
+	}
+	return ""
+}
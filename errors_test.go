@@ -0,0 +1,222 @@
+package flaeg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/containous/flaeg/parse"
+)
+
+func TestFlaegRunTypedErrors(t *testing.T) {
+	type duplicateConfig struct {
+		Name  string `description:"Name"`
+		Alias string `long:"name" description:"Alias"`
+	}
+
+	testCases := []struct {
+		desc            string
+		args            []string
+		config          interface{}
+		defaultPointers interface{}
+		noCustomParser  bool
+		strictParsers   bool
+		target          interface{}
+		expected        interface{}
+	}{
+		{
+			desc:     "unknown flag",
+			args:     []string{"--db.comx=3"},
+			target:   new(*UnknownFlagError),
+			expected: &UnknownFlagError{Flag: "db.comx", Suggestion: "db.comax"},
+		},
+		{
+			desc:     "invalid value",
+			args:     []string{"--owner.rate=high"},
+			target:   new(*InvalidValueError),
+			expected: &InvalidValueError{Flag: "owner.rate", Field: "Owner.Rate", Value: "high"},
+		},
+		{
+			desc:     "invalid value of a promoted field",
+			args:     []string{"--db.watch=maybe"},
+			target:   new(*InvalidValueError),
+			expected: &InvalidValueError{Flag: "db.watch", Field: "Db.Watch", Value: "maybe"},
+		},
+		{
+			desc:           "parser not found",
+			noCustomParser: true,
+			strictParsers:  true,
+			target:         new(*ParsersNotFoundError),
+			expected: &ParsersNotFoundError{Errors: []*ParserNotFoundError{
+				{Flag: "owner.servers", Field: "Owner.Servers", Type: reflect.TypeOf([]ServerInfo{})},
+			}},
+		},
+		{
+			desc:     "unknown command",
+			args:     []string{"verison"},
+			target:   new(*UnknownCommandError),
+			expected: &UnknownCommandError{Command: "verison", Suggestion: "version"},
+		},
+		{
+			desc:            "duplicate flag",
+			config:          &duplicateConfig{},
+			defaultPointers: &duplicateConfig{},
+			target:          new(*DuplicateFlagError),
+			expected:        &DuplicateFlagError{Flag: "name", Field: "Name"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                test.config,
+				DefaultPointersConfig: test.defaultPointers,
+				Run:                   func() error { return nil },
+			}
+			if rootCmd.Config == nil {
+				rootCmd.Config = newConfiguration()
+			}
+			if rootCmd.DefaultPointersConfig == nil {
+				rootCmd.DefaultPointersConfig = newDefaultPointersConfiguration()
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.AddCommand(&Command{
+				Name:                  "version",
+				Config:                &VersionConfig{},
+				DefaultPointersConfig: &VersionConfig{},
+				Run:                   func() error { return nil },
+			})
+			if !test.noCustomParser {
+				flaeg.AddParser(reflect.TypeOf([]ServerInfo{}), &sliceServerValue{})
+			}
+			if test.strictParsers {
+				flaeg.EnableStrictParsers()
+			}
+			flaeg.SetSilent()
+
+			err := flaeg.Run()
+			if !errors.As(err, test.target) {
+				t.Fatalf("Expected error %T got %v", test.expected, err)
+			}
+
+			got := reflect.ValueOf(test.target).Elem().Interface()
+			if invalidValueErr, ok := got.(*InvalidValueError); ok {
+				if invalidValueErr.Err == nil {
+					t.Error("Expected the error of the parser")
+				}
+				invalidValueErr.Err = nil
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestMissingDefaultPointerError(t *testing.T) {
+	cmd := &Command{Config: newConfiguration()}
+	valMap := map[string]parse.Parser{"db.comax": new(parse.UintValue)}
+
+	err := withFieldPath(fillStructRecursive(reflect.ValueOf(cmd.Config), map[string]reflect.Value{}, valMap, ""), cmd)
+
+	expected := &MissingDefaultPointerError{Flag: "db", Field: "Db"}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", expected, err)
+	}
+}
+
+func TestParserNotFoundErrorIs(t *testing.T) {
+	err := error(&ParserNotFoundError{Flag: "owner.servers", Type: reflect.TypeOf([]ServerInfo{})})
+	if !errors.Is(err, ErrParserNotFound) {
+		t.Errorf("Expected %v to be %v", err, ErrParserNotFound)
+	}
+}
+
+func TestFlaegRunSourceTypedErrors(t *testing.T) {
+	type subConfig struct {
+		Port int `description:"Port"`
+	}
+	type sourceConfig struct {
+		Name string     `required:"true" description:"Name"`
+		Sub  *subConfig `description:"Enable sub"`
+	}
+
+	testCases := []struct {
+		desc     string
+		env      map[string]string
+		values   map[string]interface{}
+		target   interface{}
+		expected interface{}
+	}{
+		{
+			desc:     "required flag",
+			target:   new(*FieldError),
+			expected: &FieldError{Flag: "name", Err: ErrRequired},
+		},
+		{
+			desc:     "invalid value from the environment",
+			env:      map[string]string{"P_NAME": "foo", "P_SUB_PORT": "abc"},
+			target:   new(*InvalidValueError),
+			expected: &InvalidValueError{Flag: "sub.port", Field: "Sub.Port", Value: "abc", Source: "env"},
+		},
+		{
+			desc:     "unknown flag in a source",
+			values:   map[string]interface{}{"name": "foo", "sub.host": "localhost"},
+			target:   new(*UnknownFlagError),
+			expected: &UnknownFlagError{Flag: "sub.host", Source: "defaults"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &sourceConfig{},
+				DefaultPointersConfig: &sourceConfig{Sub: &subConfig{}},
+				Run:                   func() error { return nil },
+			}
+
+			flaeg := New(rootCmd, nil)
+			flaeg.SetEnv(&EnvSource{Prefix: "P", LookupEnv: lookupEnvMap(test.env)})
+			flaeg.AddSource(NewMapSource("defaults", test.values), PriorityDefaults)
+			flaeg.SetSilent()
+
+			err := flaeg.Run()
+			if !errors.As(err, test.target) {
+				t.Fatalf("Expected error %T got %v", test.expected, err)
+			}
+
+			got := reflect.ValueOf(test.target).Elem().Interface()
+			if invalidValueErr, ok := got.(*InvalidValueError); ok {
+				if invalidValueErr.Err == nil {
+					t.Error("Expected the error of the parser")
+				}
+				invalidValueErr.Err = nil
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidationErrorIs(t *testing.T) {
+	err := error(newValidationError([]*FieldError{
+		{Flag: "port", Err: errors.New("too low")},
+		{Flag: "name", Err: ErrRequired},
+	}))
+	if !errors.Is(err, ErrRequired) {
+		t.Errorf("Expected %v to be %v", err, ErrRequired)
+	}
+	if errors.Is(err, ErrParserNotFound) {
+		t.Errorf("Expected %v not to be %v", err, ErrParserNotFound)
+	}
+}
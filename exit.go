@@ -0,0 +1,65 @@
+package flaeg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	flag "github.com/ogier/pflag"
+)
+
+// ExitError is an error carrying the exit code of the program
+type ExitError struct {
+	Code int
+	// Err is printed before exiting, if it is not nil
+	Err error
+}
+
+// NewExitError returns an error making Main exit with code, after printing err if it is not nil
+func NewExitError(code int, err error) *ExitError {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the error printed before exiting
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Main runs the called command and exits with the exit code returned by Execute
+func (f *Flaeg) Main() {
+	os.Exit(f.Execute())
+}
+
+// Execute runs the called command and returns its exit code, instead of exiting like Main:
+// - 0 on success, after printing the help or the configuration
+// - the code of an ExitError, possibly wrapped
+// - 1 on any other error
+// The error is printed to the error output, unless it has already been printed with the help.
+func (f *Flaeg) Execute() int {
+	err := f.Run()
+	if err == nil || errors.Is(err, flag.ErrHelp) || errors.Is(err, ErrPrintConfig) {
+		return 0
+	}
+
+	code := 1
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.Code
+		// a wrapped ExitError is printed with the message of the wrapping error
+		if err == error(exitErr) {
+			err = exitErr.Err
+		}
+	}
+
+	if err != nil && !f.errorPrinted {
+		fmt.Fprintf(writerOrDefault(f.errOutput, os.Stderr), "Error: %s\n", err)
+	}
+	return code
+}
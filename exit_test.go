@@ -0,0 +1,105 @@
+package flaeg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFlaegExecute(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		args           []string
+		runErr         error
+		expected       int
+		expectedStderr string
+	}{
+		{
+			desc:     "success",
+			expected: 0,
+		},
+		{
+			desc:     "help",
+			args:     []string{"--help"},
+			expected: 0,
+		},
+		{
+			desc:           "run error",
+			runErr:         errors.New("boom"),
+			expected:       1,
+			expectedStderr: "Error: boom\n",
+		},
+		{
+			desc:           "exit error",
+			runErr:         NewExitError(3, errors.New("boom")),
+			expected:       3,
+			expectedStderr: "Error: boom\n",
+		},
+		{
+			desc:           "wrapped exit error",
+			runErr:         fmt.Errorf("run: %w", NewExitError(3, errors.New("boom"))),
+			expected:       3,
+			expectedStderr: "Error: run: boom\n",
+		},
+		{
+			desc:     "exit error without error",
+			runErr:   NewExitError(4, nil),
+			expected: 4,
+		},
+		{
+			desc:           "error printed with the help",
+			args:           []string{"--unknown"},
+			expected:       1,
+			expectedStderr: "Error: unknown flag --unknown\nUsage: flaegtest [flags]",
+		},
+	}
+
+	backupStdout, backupStderr := os.Stdout, os.Stderr
+	defer func() {
+		os.Stdout, os.Stderr = backupStdout, backupStderr
+	}()
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &VersionConfig{},
+				DefaultPointersConfig: &VersionConfig{},
+				Run: func() error {
+					return test.runErr
+				},
+			}
+
+			// catch stdout and stderr
+			_, wOut, _ := os.Pipe()
+			rErr, wErr, _ := os.Pipe()
+			os.Stdout, os.Stderr = wOut, wErr
+
+			code := New(rootCmd, test.args).Execute()
+
+			// read and restore stdout and stderr
+			_ = wOut.Close()
+			if err := wErr.Close(); err != nil {
+				t.Fatal(err)
+			}
+			stderr, err := ioutil.ReadAll(rErr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			os.Stdout, os.Stderr = backupStdout, backupStderr
+
+			if code != test.expected {
+				t.Errorf("Expected exit code %d got %d", test.expected, code)
+			}
+			// the error is printed once
+			if !strings.HasPrefix(string(stderr), test.expectedStderr) || strings.Count(string(stderr), "Error:") > 1 ||
+				len(test.expectedStderr) == 0 && len(stderr) > 0 {
+				t.Errorf("Expected stderr %q got %q", test.expectedStderr, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package flaeg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/flaeg/parse"
+	"gopkg.in/yaml.v2"
+)
+
+// configFileFlag is the name of the built-in flag giving the path of the configuration file
+const configFileFlag = "configfile"
+
+// FileSource is a Source of flags values read from a configuration file.
+// The format of the file is detected from its extension: .toml, .json, .yaml or .yml
+// Keys are the flags names: tables (or objects) are sub-structures,
+// and a table on a pointer field enables it with its DefaultPointersConfig values.
+type FileSource struct {
+	Path string
+}
+
+// NewFileSource creates a FileSource reading the file at path
+func NewFileSource(path string) *FileSource {
+	return &FileSource{Path: path}
+}
+
+// Name returns the name of the FileSource
+func (f *FileSource) Name() string {
+	return "file"
+}
+
+// Values returns the flags values found in the file
+func (f *FileSource) Values(flagMap map[string]reflect.StructField) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := decodeFile(f.Path, content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %v", f.Path, err)
+	}
+
+	values := make(map[string]interface{})
+	if err := flattenFileValues(values, data, flagMap, ""); err != nil {
+		return nil, fmt.Errorf("%s: %v", f.Path, err)
+	}
+	return values, nil
+}
+
+// decodeFile decodes content in a generic map, according to the extension of path
+func decodeFile(path string, content []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		if _, err := toml.Decode(string(content), &data); err != nil {
+			return nil, err
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file format %q", ext)
+	}
+
+	return data, nil
+}
+
+// flattenFileValues fills values with the content of data, keyed by lowercase dotted flags names
+func flattenFileValues(values map[string]interface{}, data map[string]interface{}, flagMap map[string]reflect.StructField, key string) error {
+	for k, v := range data {
+		name := strings.ToLower(k)
+		if len(key) > 0 {
+			name = key + "." + name
+		}
+
+		switch value := v.(type) {
+		case nil:
+			// null values are ignored
+		case map[interface{}]interface{}:
+			if err := flattenFileSection(values, yamlMapToStringMap(value), flagMap, name); err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			if err := flattenFileSection(values, value, flagMap, name); err != nil {
+				return err
+			}
+		case []interface{}:
+			list, err := fileListToStrings(value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			values[name] = list
+		default:
+			str, err := fileValueToString(value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			values[name] = str
+		}
+	}
+	return nil
+}
+
+// flattenFileSection fills values with the content of the table name.
+// The values of a table enable its pointer field like flags do,
+// an empty table enables it explicitly.
+func flattenFileSection(values map[string]interface{}, data map[string]interface{}, flagMap map[string]reflect.StructField, name string) error {
+	if len(data) > 0 {
+		return flattenFileValues(values, data, flagMap, name)
+	}
+
+	if field, ok := flagMap[name]; ok && field.Type.Kind() == reflect.Bool {
+		if _, ok := values[name]; !ok {
+			values[name] = "true"
+		}
+	}
+	return nil
+}
+
+func yamlMapToStringMap(in map[interface{}]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[fmt.Sprint(k)] = v
+	}
+	return out
+}
+
+// fileListToStrings formats the elements of a decoded list as flag arguments, null elements are ignored
+func fileListToStrings(list []interface{}) ([]string, error) {
+	strs := make([]string, 0, len(list))
+	for _, elem := range list {
+		if elem == nil {
+			continue
+		}
+		str, err := fileValueToString(elem)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+// fileValueToString formats a decoded scalar value as a flag argument
+func fileValueToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool, int, int64, uint64, json.Number:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v (%T)", value, value)
+	}
+}
+
+// addConfigFileFlag adds the built-in flag configfile in flagMap, with its default value in defaultValMap
+func addConfigFileFlag(flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, defaultPath string) error {
+	if _, ok := flagMap[configFileFlag]; ok {
+		return &DuplicateFlagError{Flag: configFileFlag}
+	}
+
+	flagMap[configFileFlag] = reflect.StructField{
+		Name: "ConfigFile",
+		Type: reflect.TypeOf(""),
+		Tag:  `description:"Configuration file (TOML, JSON or YAML)"`,
+	}
+	defaultValMap[configFileFlag] = reflect.ValueOf(defaultPath)
+	return nil
+}
+
+// configFileLayer returns the layer of the file given by the flag configfile.
+// The file at defaultPath is loaded if the flag is not called and if it exists.
+func configFileLayer(valMap map[string]parse.Parser, defaultPath string) (layer, bool) {
+	path := defaultPath
+	if parser, ok := valMap[configFileFlag]; ok {
+		path = parser.Get().(string)
+	} else if _, err := os.Stat(path); err != nil {
+		return layer{}, false
+	}
+
+	if len(path) == 0 {
+		return layer{}, false
+	}
+	return layer{source: NewFileSource(path), priority: PriorityFile}, true
+}
@@ -0,0 +1,239 @@
+package flaeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg/parse"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "flaeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileSourceValues(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		name    string
+		content string
+	}{
+		{
+			desc: "toml",
+			name: "config.toml",
+			content: `
+loglevel = "WARN"
+timeout = "3s"
+
+[db]
+ip = "10.0.0.1"
+comax = 5000
+
+[owner]
+dob = 2016-04-20T17:39:00Z
+rate = 0.5
+servers = ["1.0.0.1", "1.0.0.2"]
+`,
+		},
+		{
+			desc: "json",
+			name: "config.json",
+			content: `{
+  "logLevel": "WARN",
+  "timeout": "3s",
+  "db": {"ip": "10.0.0.1", "comax": 5000, "connectionmax64": null},
+  "owner": {"dob": "2016-04-20T17:39:00Z", "rate": 0.5, "servers": ["1.0.0.1", "1.0.0.2"]}
+}`,
+		},
+		{
+			desc: "yaml",
+			name: "config.yml",
+			content: `
+loglevel: WARN
+timeout: 3s
+db:
+  ip: 10.0.0.1
+  comax: 5000
+  connectionmax64:
+owner:
+  dob: 2016-04-20T17:39:00Z
+  rate: 0.5
+  servers:
+    - 1.0.0.1
+    - 1.0.0.2
+`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeConfigFile(t, test.name, test.content)
+			defer func() { _ = os.RemoveAll(filepath.Dir(path)) }()
+
+			config := newConfiguration()
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: newDefaultPointersConfiguration(),
+			}
+			customParsers := map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			}
+
+			if err := LoadWithCommand(cmd, nil, customParsers, nil, WithSource(NewFileSource(path), PriorityFile)); err != nil {
+				t.Fatal(err)
+			}
+
+			check := newConfiguration()
+			check.LogLevel = "WARN"
+			check.Timeout = parse.Duration(3 * time.Second)
+			check.Db = newDefaultPointersConfiguration().Db
+			check.Db.IP = "10.0.0.1"
+			check.Db.ConnectionMax = 5000
+			check.Owner.DateOfBirth, _ = time.Parse(time.RFC3339, "2016-04-20T17:39:00Z")
+			check.Owner.Rate = 0.5
+			check.Owner.Servers = []ServerInfo{{IP: "1.0.0.1"}, {IP: "1.0.0.2"}}
+
+			if !reflect.DeepEqual(config, check) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", check, config)
+			}
+		})
+	}
+}
+
+func TestFileSourceValuesErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			desc:     "unsupported format",
+			name:     "config.ini",
+			content:  "loglevel=WARN",
+			expected: `unsupported file format ".ini"`,
+		},
+		{
+			desc:     "unknown key",
+			name:     "config.toml",
+			content:  "[db]\nunknown = 1",
+			expected: "unknown flag --db.unknown in file",
+		},
+		{
+			desc:     "invalid syntax",
+			name:     "config.json",
+			content:  `{"loglevel": }`,
+			expected: "unable to decode",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeConfigFile(t, test.name, test.content)
+			defer func() { _ = os.RemoveAll(filepath.Dir(path)) }()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                newConfiguration(),
+				DefaultPointersConfig: newDefaultPointersConfiguration(),
+			}
+			customParsers := map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			}
+
+			err := LoadWithCommand(cmd, nil, customParsers, nil, WithSource(NewFileSource(path), PriorityFile))
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error %q got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFlaegConfigFileFlag(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", "loglevel = \"WARN\"\n[db]\nload = 3\n")
+	defer func() { _ = os.RemoveAll(filepath.Dir(path)) }()
+
+	testCases := []struct {
+		desc        string
+		defaultPath string
+		args        []string
+		expected    func() *Configuration
+	}{
+		{
+			desc: "flag",
+			args: []string{"--configfile=" + path, "--db.load=5"},
+			expected: func() *Configuration {
+				check := newConfiguration()
+				check.LogLevel = "WARN"
+				check.Db = newDefaultPointersConfiguration().Db
+				check.Db.Load = 5
+				return check
+			},
+		},
+		{
+			desc:        "default path",
+			defaultPath: path,
+			expected: func() *Configuration {
+				check := newConfiguration()
+				check.LogLevel = "WARN"
+				check.Db = newDefaultPointersConfiguration().Db
+				check.Db.Load = 3
+				return check
+			},
+		},
+		{
+			desc:        "missing default path",
+			defaultPath: filepath.Join(filepath.Dir(path), "missing.toml"),
+			expected:    newConfiguration,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			config := newConfiguration()
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: newDefaultPointersConfiguration(),
+				Run: func() error {
+					return nil
+				},
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.AddParser(reflect.TypeOf([]ServerInfo{}), &sliceServerValue{})
+			flaeg.SetConfigFileFlag(test.defaultPath)
+
+			if err := flaeg.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if check := test.expected(); !reflect.DeepEqual(config, check) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", check, config)
+			}
+		})
+	}
+}
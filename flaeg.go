@@ -1,11 +1,13 @@
 package flaeg
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path"
 	"reflect"
 	"sort"
@@ -32,7 +34,7 @@ func getTypesRecursive(objValue reflect.Value, flagMap map[string]reflect.Struct
 				if err := getTypesRecursive(objValue.Field(i), flagMap, name); err != nil {
 					return err
 				}
-			} else if len(objValue.Type().Field(i).Tag.Get("description")) > 0 {
+			} else if isFlagField(objValue.Type().Field(i)) {
 				fieldName := objValue.Type().Field(i).Name
 				if !isExported(fieldName) {
 					return fmt.Errorf("field %s is an unexported field", fieldName)
@@ -49,7 +51,7 @@ func getTypesRecursive(objValue reflect.Value, flagMap map[string]reflect.Struct
 				}
 
 				if _, ok := flagMap[name]; ok {
-					return fmt.Errorf("tag already exists: %s", name)
+					return &DuplicateFlagError{Flag: name}
 				}
 				flagMap[name] = objValue.Type().Field(i)
 
@@ -110,34 +112,46 @@ func GetFlags(config interface{}) ([]string, error) {
 // ParseArgs : parses args return a map[flag]Getter, using parsers map[type]Getter
 // args must be formatted as like as flag documentation. See https://golang.org/pkg/flag
 func parseArgs(args []string, flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser) (map[string]parse.Parser, error) {
+	valMap, _, invalidValues, err := parseFlagSet(args, flagMap, parsers)
+	if len(invalidValues) > 0 {
+		return nil, invalidValues[0].Err
+	}
+	return valMap, err
+}
+
+// parseFlagSet is like parseArgs, it returns the positional arguments as well.
+// The flags with an invalid value are not returned with the values, they are reported as FieldError wrapping an InvalidValueError.
+func parseFlagSet(args []string, flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser) (map[string]parse.Parser, []string, []*FieldError, error) {
 	newParsers := map[string]parse.Parser{}
 	flagSet := flag.NewFlagSet("flaeg.Load", flag.ContinueOnError)
 
 	// Disable output
 	flagSet.SetOutput(ioutil.Discard)
 
-	var err error
+	var invalidValues []*FieldError
 	for flg, structField := range flagMap {
-		if parser, ok := parsers[structField.Type]; ok {
-			newParserValue := reflect.New(reflect.TypeOf(parser).Elem())
-			newParserValue.Elem().Set(reflect.ValueOf(parser).Elem())
-			newParser := newParserValue.Interface().(parse.Parser)
+		if parser, ok := findParser(parsers, structField.Type); ok {
+			newParser := cloneParser(parser)
+			value := &flagValue{Parser: newParser, flag: flg, invalidValues: &invalidValues}
 
 			if short := structField.Tag.Get("short"); len(short) == 1 {
-				flagSet.VarP(newParser, flg, short, structField.Tag.Get("description"))
+				flagSet.VarP(value, flg, short, structField.Tag.Get("description"))
 			} else {
-				flagSet.Var(newParser, flg, structField.Tag.Get("description"))
+				flagSet.Var(value, flg, structField.Tag.Get("description"))
 			}
 			newParsers[flg] = newParser
-		} else {
-			err = ErrParserNotFound
 		}
 	}
 
 	// prevents case sensitivity issue
 	args = argsToLower(args)
 	if errParse := flagSet.Parse(args); errParse != nil {
-		return nil, errParse
+		return nil, nil, nil, unknownFlagError(errParse, flagMap)
+	}
+
+	inError := make(map[string]bool, len(invalidValues))
+	for _, fieldError := range invalidValues {
+		inError[fieldError.Flag] = true
 	}
 
 	// Visitor in flag.Parse
@@ -154,10 +168,48 @@ func parseArgs(args []string, flagMap map[string]reflect.StructField, parsers ma
 
 	// Return parsers on parsed flag
 	for _, flg := range flagList {
-		valMap[flg.Name] = newParsers[flg.Name]
+		if !inError[flg.Name] {
+			valMap[flg.Name] = newParsers[flg.Name]
+		}
 	}
 
-	return valMap, err
+	if missingParsers(flagMap, parsers) != nil {
+		// the fields without parser are listed by CheckParsers and by the strict mode
+		return valMap, flagSet.Args(), invalidValues, ErrParserNotFound
+	}
+	return valMap, flagSet.Args(), invalidValues, nil
+}
+
+// flagValue is the value of a flag in the flag set.
+// It records the errors of its parser in invalidValues, so that the parsing goes on with the next flags.
+type flagValue struct {
+	parse.Parser
+	flag          string
+	invalidValues *[]*FieldError
+}
+
+func (v *flagValue) Set(s string) error {
+	if err := v.Parser.Set(s); err != nil {
+		*v.invalidValues = append(*v.invalidValues, &FieldError{Flag: v.flag, Err: &InvalidValueError{Flag: v.flag, Value: s, Err: err}})
+	}
+	return nil
+}
+
+// IsBoolFlag returns true if the parser is a bool flag, called without value
+func (v *flagValue) IsBoolFlag() bool {
+	boolFlag, ok := v.Parser.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// cloneParser returns a new parser holding the same value as parser
+func cloneParser(parser parse.Parser) parse.Parser {
+	if derived, ok := parser.(derivedParser); ok {
+		return derived.clone()
+	}
+
+	newParserValue := reflect.New(reflect.TypeOf(parser).Elem())
+	newParserValue.Elem().Set(reflect.ValueOf(parser).Elem())
+	return newParserValue.Interface().(parse.Parser)
 }
 
 func getDefaultValue(defaultValue reflect.Value, defaultPointersValue reflect.Value, defaultValmap map[string]reflect.Value, key string) error {
@@ -173,7 +225,7 @@ func getDefaultValue(defaultValue reflect.Value, defaultPointersValue reflect.Va
 				if err := getDefaultValue(defaultValue.Field(i), defaultPointersValue.Field(i), defaultValmap, name); err != nil {
 					return err
 				}
-			} else if len(defaultValue.Type().Field(i).Tag.Get("description")) > 0 {
+			} else if isFlagField(defaultValue.Type().Field(i)) {
 				fieldName := defaultValue.Type().Field(i).Name
 				if tag := defaultValue.Type().Field(i).Tag.Get("long"); len(tag) > 0 {
 					fieldName = tag
@@ -272,7 +324,7 @@ func fillStructRecursive(objValue reflect.Value, defaultPointerValMap map[string
 				if err := fillStructRecursive(objValue.Field(i), defaultPointerValMap, valMap, name); err != nil {
 					return err
 				}
-			} else if len(objValue.Type().Field(i).Tag.Get("description")) > 0 {
+			} else if isFlagField(objValue.Type().Field(i)) {
 				fieldName := objValue.Type().Field(i).Name
 				if tag := objValue.Type().Field(i).Tag.Get("long"); len(tag) > 0 {
 					fieldName = tag
@@ -325,7 +377,7 @@ func fillStructRecursive(objValue reflect.Value, defaultPointerValMap map[string
 				// set default pointer value
 				objValue.Set(defVal)
 			} else {
-				return fmt.Errorf("flag %s default value not provided", name)
+				return &MissingDefaultPointerError{Flag: name}
 			}
 		}
 
@@ -342,10 +394,52 @@ func fillStructRecursive(objValue reflect.Value, defaultPointerValMap map[string
 	return nil
 }
 
+// visitFields calls visit on every flagged field of objValue with its flag name and its value.
+// Fields under nil pointers are not visited.
+func visitFields(objValue reflect.Value, key string, visit func(name string, field reflect.StructField, fieldValue reflect.Value) error) error {
+	switch objValue.Kind() {
+	case reflect.Struct:
+		for i := 0; i < objValue.NumField(); i++ {
+			field := objValue.Type().Field(i)
+			if field.Anonymous {
+				if err := visitFields(objValue.Field(i), key, visit); err != nil {
+					return err
+				}
+			} else if isFlagField(field) {
+				name := flagName(key, field)
+				if err := visit(name, field, objValue.Field(i)); err != nil {
+					return err
+				}
+				if err := visitFields(objValue.Field(i), name, visit); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Ptr:
+		if !objValue.IsNil() {
+			return visitFields(objValue.Elem(), key, visit)
+		}
+	}
+	return nil
+}
+
+// flagName returns the flag name of a field in the struct flagged key
+func flagName(key string, field reflect.StructField) string {
+	fieldName := field.Name
+	if tag := field.Tag.Get("long"); len(tag) > 0 {
+		fieldName = tag
+	}
+
+	if len(key) == 0 {
+		return strings.ToLower(fieldName)
+	}
+	return key + "." + strings.ToLower(fieldName)
+}
+
 // SetFields sets value to fieldValue using tag as key in valMap
 func setFields(fieldValue reflect.Value, val parse.Parser) error {
 	if fieldValue.CanSet() {
-		fieldValue.Set(reflect.ValueOf(val).Elem().Convert(fieldValue.Type()))
+		fieldValue.Set(parserValue(val).Convert(fieldValue.Type()))
 	} else {
 		return fmt.Errorf("%s is not settable", fieldValue.Type().String())
 	}
@@ -362,7 +456,7 @@ func PrintError(err error, flagMap map[string]reflect.StructField, defaultValmap
 	if err != flag.ErrHelp {
 		fmt.Printf("Error: %s\n", err)
 	}
-	if !strings.Contains(err.Error(), ":No parser for type") {
+	if !errors.Is(err, ErrParserNotFound) {
 		_ = PrintHelp(flagMap, defaultValmap, parsers)
 	}
 	return err
@@ -370,19 +464,19 @@ func PrintError(err error, flagMap map[string]reflect.StructField, defaultValmap
 
 // LoadWithParsers initializes config : struct fields given by reference, with args : arguments.
 // Some custom parsers may be given.
-func LoadWithParsers(config interface{}, defaultValue interface{}, args []string, customParsers map[reflect.Type]parse.Parser) error {
+func LoadWithParsers(config interface{}, defaultValue interface{}, args []string, customParsers map[reflect.Type]parse.Parser, opts ...Option) error {
 	cmd := &Command{
 		Config:                config,
 		DefaultPointersConfig: defaultValue,
 	}
 	_, cmd.Name = path.Split(os.Args[0])
-	return LoadWithCommand(cmd, args, customParsers, nil)
+	return LoadWithCommand(cmd, args, customParsers, nil, opts...)
 }
 
 // Load initializes config : struct fields given by reference, with args : arguments.
 // Some custom parsers may be given.
-func Load(config interface{}, defaultValue interface{}, args []string) error {
-	return LoadWithParsers(config, defaultValue, args, nil)
+func Load(config interface{}, defaultValue interface{}, args []string, opts ...Option) error {
+	return LoadWithParsers(config, defaultValue, args, nil, opts...)
 }
 
 // Command structure contains program/command information (command name and description)
@@ -392,64 +486,63 @@ func Load(config interface{}, defaultValue interface{}, args []string) error {
 // Run is the func which launch the program using initialized configuration structure
 type Command struct {
 	Name                  string
+	Aliases               []string
 	Description           string
 	Config                interface{}
 	DefaultPointersConfig interface{} // TODO: case DefaultPointersConfig is nil
 	Run                   func() error
 	Metadata              map[string]string
 	HideHelp              bool
+	// RunWithContext is called instead of Run if it is not nil, with the remaining positional arguments.
+	// The context is cancelled on SIGINT or SIGTERM.
+	RunWithContext func(ctx context.Context, args []string) error
+	// PersistentFlags makes the flags of Config global: they are accepted by the sub-commands as well
+	PersistentFlags bool
+	// PreRun and PostRun are called before and after the run of the command.
+	// PersistentPreRun and PersistentPostRun are called for the command and for its sub-commands.
+	PreRun            func() error
+	PostRun           func() error
+	PersistentPreRun  func() error
+	PersistentPostRun func() error
+
+	exclusiveFlags   [][]string
+	flagDependencies []flagDependency
+	parent           *Command
+	subCommands      []*Command
 }
 
 // LoadWithCommand initializes config : struct fields given by reference, with args : arguments.
 // Some custom parsers and some subCommand may be given.
-func LoadWithCommand(cmd *Command, cmdArgs []string, customParsers map[reflect.Type]parse.Parser, subCommand []*Command) error {
-	parsers, err := parse.LoadParsers(customParsers)
-	if err != nil {
-		return err
-	}
-
-	tagsMap := make(map[string]reflect.StructField)
-	if err := getTypesRecursive(reflect.ValueOf(cmd.Config), tagsMap, ""); err != nil {
-		return err
-	}
-	defaultValMap := make(map[string]reflect.Value)
-	if err := getDefaultValue(reflect.ValueOf(cmd.Config), reflect.ValueOf(cmd.DefaultPointersConfig), defaultValMap, ""); err != nil {
-		return err
-	}
-
-	valMap, errParseArgs := parseArgs(cmdArgs, tagsMap, parsers)
-	if errParseArgs != nil && errParseArgs != ErrParserNotFound {
-		return PrintErrorWithCommand(errParseArgs, tagsMap, defaultValMap, parsers, cmd, subCommand)
-	}
-
-	if err := fillStructRecursive(reflect.ValueOf(cmd.Config), defaultValMap, valMap, ""); err != nil {
-		return err
-	}
-
-	if errParseArgs == ErrParserNotFound {
-		return errParseArgs
-	}
-
-	return nil
+func LoadWithCommand(cmd *Command, cmdArgs []string, customParsers map[reflect.Type]parse.Parser, subCommand []*Command, opts ...Option) error {
+	_, err := loadCommand(cmd, cmdArgs, customParsers, subCommand, newLoadOptions(opts))
+	return err
 }
 
 // PrintHelpWithCommand generates and prints command line help for a Command
 func PrintHelpWithCommand(flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, parsers map[reflect.Type]parse.Parser, cmd *Command, subCmd []*Command) error {
+	return printHelpWithCommand(os.Stdout, flagMap, defaultValMap, parsers, cmd, subCmd)
+}
+
+// printHelpWithCommand is like PrintHelpWithCommand, it prints the help to output
+func printHelpWithCommand(output io.Writer, flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, parsers map[reflect.Type]parse.Parser, cmd *Command, subCmd []*Command) error {
 	// Hide command from help
 	if cmd != nil && cmd.HideHelp {
-		return fmt.Errorf("command %s not found", cmd.Name)
+		return &UnknownCommandError{Command: cmd.Name}
 	}
 
 	// Define a templates
 	// Using POSXE STD : http://pubs.opengroup.org/onlinepubs/9699919799/
 	const helper = `{{if .ProgDescription}}{{.ProgDescription}}
 
-{{end}}Usage: {{.ProgName}} [flags] <command> [<arguments>]
-
+{{end}}Usage: {{.ProgName}} [flags] {{if .Arguments}}{{.Arguments}}{{else}}<command> [<arguments>]{{end}}
+{{if or .SubCommands (not .Arguments)}}
 Use "{{.ProgName}} <command> --help" for help on any command.
-{{if .SubCommands}}
+{{end}}{{if .SubCommands}}
 Commands:{{range $subCmdName, $subCmdDesc := .SubCommands}}
 {{printf "\t%-50s %s" $subCmdName $subCmdDesc}}{{end}}
+{{end}}{{if .ArgumentsDescriptions}}
+Arguments:{{range .ArgumentsDescriptions}}
+{{printf "\t%-50s %s" .Name .Description}}{{end}}
 {{end}}
 Flag's usage: {{.ProgName}} [--flag=flag_argument] [-f[flag_argument]] ...     set flag_argument to flag(s)
           or: {{.ProgName}} [--flag[=true|false| ]] [-f[true|false| ]] ...     set true/false to boolean flag(s)
@@ -458,20 +551,26 @@ Flags:
 `
 	// Use a struct to give data to template
 	type TempStruct struct {
-		ProgName        string
-		ProgDescription string
-		SubCommands     map[string]string
+		ProgName              string
+		ProgDescription       string
+		SubCommands           map[string]string
+		Arguments             string
+		ArgumentsDescriptions []argumentHelp
 	}
 	tempStruct := TempStruct{}
 	if cmd != nil {
-		tempStruct.ProgName = cmd.Name
+		var err error
+		tempStruct.Arguments, tempStruct.ArgumentsDescriptions, err = argumentsHelp(cmd.Config)
+		if err != nil {
+			return err
+		}
+
+		tempStruct.ProgName = cmd.Path()
 		tempStruct.ProgDescription = cmd.Description
 		tempStruct.SubCommands = map[string]string{}
-		if len(subCmd) > 1 && cmd == subCmd[0] {
-			for _, c := range subCmd[1:] {
-				if !c.HideHelp {
-					tempStruct.SubCommands[c.Name] = c.Description
-				}
+		for _, c := range helpSubCommands(cmd, subCmd) {
+			if !c.HideHelp {
+				tempStruct.SubCommands[c.helpName()] = c.Description
 			}
 		}
 	} else {
@@ -483,19 +582,42 @@ Flags:
 	if err != nil {
 		return err
 	}
-	err = tmplHelper.Execute(os.Stdout, tempStruct)
+	err = tmplHelper.Execute(output, tempStruct)
 	if err != nil {
 		return err
 	}
 
-	return printFlagsDescriptionsDefaultValues(flagMap, defaultValMap, parsers, os.Stdout)
+	if err := printFlagsDescriptionsDefaultValues(flagMap, defaultValMap, parsers, output); err != nil {
+		return err
+	}
+
+	if cmd != nil {
+		if err := printFlagGroups(cmd, output); err != nil {
+			return err
+		}
+		return printGlobalFlags(cmd, parsers, output)
+	}
+	return nil
 }
 
 func printFlagsDescriptionsDefaultValues(flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, parsers map[reflect.Type]parse.Parser, output io.Writer) error {
+	shortFlagsWithDash, flagsWithDash, descriptions, defaultValues := flagsDescriptionsDefaultValues(flagMap, defaultValMap, parsers)
+
+	// add help flag
+	shortFlagsWithDash = append(shortFlagsWithDash, "-h,")
+	flagsWithDash = append(flagsWithDash, "--help")
+	descriptions = append(descriptions, "Print Help (this message) and exit")
+	defaultValues = append(defaultValues, "")
+
+	return displayTab(output, shortFlagsWithDash, flagsWithDash, descriptions, defaultValues)
+}
+
+// flagsDescriptionsDefaultValues returns the columns of the help of the flags: short flags, flags, descriptions and default values
+func flagsDescriptionsDefaultValues(flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, parsers map[reflect.Type]parse.Parser) ([]string, []string, []string, []string) {
 	// Sort alphabetically & Delete unparsable flags in a slice
 	var flags []string
 	for flg, field := range flagMap {
-		if _, ok := parsers[field.Type]; ok {
+		if _, ok := findParser(parsers, field.Type); ok {
 			flags = append(flags, flg)
 		}
 	}
@@ -517,16 +639,23 @@ func printFlagsDescriptionsDefaultValues(flagMap map[string]reflect.StructField,
 
 		// flag on pointer ?
 		if defVal, ok := defaultValMap[flg]; ok {
+			parser, _ := findParser(parsers, field.Type)
 			if defVal.Kind() != reflect.Ptr {
 				// Set defaultValue on parsers
-				parsers[field.Type].SetValue(defaultValMap[flg].Interface())
+				parser.SetValue(defaultValMap[flg].Interface())
 			}
 
-			if defVal := parsers[field.Type].String(); len(defVal) > 0 {
-				defaultValues = append(defaultValues, fmt.Sprintf("(default \"%s\")", defVal))
-			} else {
-				defaultValues = append(defaultValues, "")
+			var defaultValue string
+			if defVal := parser.String(); len(defVal) > 0 {
+				defaultValue = fmt.Sprintf("(default \"%s\")", defVal)
+			}
+			if constraints := constraintsDescription(field); len(constraints) > 0 {
+				defaultValue = strings.TrimSpace(defaultValue + " " + constraints)
 			}
+			if field.Tag.Get("required") == "true" {
+				defaultValue = strings.TrimSpace(defaultValue + " (required)")
+			}
+			defaultValues = append(defaultValues, defaultValue)
 		}
 
 		splittedDescriptions := split(field.Tag.Get("description"), 80)
@@ -540,13 +669,7 @@ func printFlagsDescriptionsDefaultValues(flagMap map[string]reflect.StructField,
 		}
 	}
 
-	// add help flag
-	shortFlagsWithDash = append(shortFlagsWithDash, "-h,")
-	flagsWithDash = append(flagsWithDash, "--help")
-	descriptions = append(descriptions, "Print Help (this message) and exit")
-	defaultValues = append(defaultValues, "")
-
-	return displayTab(output, shortFlagsWithDash, flagsWithDash, descriptions, defaultValues)
+	return shortFlagsWithDash, flagsWithDash, descriptions, defaultValues
 }
 
 func split(str string, width int) []string {
@@ -584,11 +707,18 @@ func displayTab(output io.Writer, columns ...[]string) error {
 
 // PrintErrorWithCommand takes a not nil error and prints command line help
 func PrintErrorWithCommand(err error, flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, parsers map[reflect.Type]parse.Parser, cmd *Command, subCmd []*Command) error {
+	return printErrorWithCommand(os.Stdout, os.Stdout, err, flagMap, defaultValMap, parsers, cmd, subCmd)
+}
+
+// printErrorWithCommand is like PrintErrorWithCommand:
+// the help requested by flag.ErrHelp is printed to output, the other errors are printed to errOutput followed by the help
+func printErrorWithCommand(output io.Writer, errOutput io.Writer, err error, flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, parsers map[reflect.Type]parse.Parser, cmd *Command, subCmd []*Command) error {
 	if err != flag.ErrHelp {
-		fmt.Printf("Error here : %s\n", err)
+		fmt.Fprintf(errOutput, "Error: %s\n", err)
+		output = errOutput
 	}
 
-	if errHelp := PrintHelpWithCommand(flagMap, defaultValMap, parsers, cmd, subCmd); errHelp != nil {
+	if errHelp := printHelpWithCommand(output, flagMap, defaultValMap, parsers, cmd, subCmd); errHelp != nil {
 		return errHelp
 	}
 
@@ -599,25 +729,42 @@ func PrintErrorWithCommand(err error, flagMap map[string]reflect.StructField, de
 // and row arguments (command and/or flags)
 // a map of custom parsers could be use
 type Flaeg struct {
-	calledCommand *Command
-	commands      []*Command // rootCommand is th fist one in this slice
-	args          []string
-	commandArgs   []string
-	customParsers map[reflect.Type]parse.Parser
+	calledCommand  *Command
+	rootCommand    *Command
+	args           []string
+	commandArgs    []string
+	customParsers  map[reflect.Type]parse.Parser
+	sources        []layer
+	configFile     *string
+	printConfig    bool
+	strictParsers  bool
+	prefixMatching bool
+	origins        map[string]string
+	// positionalArgs are the positional arguments of the called command not bound to its fields
+	positionalArgs []string
+	notifySignals  SignalNotifier
+	middlewares    []Middleware
+	// errorPrinted is true if the error of the last parsed command has already been printed
+	errorPrinted bool
+	// output receives the help and the printed config, errOutput receives the errors.
+	// They are os.Stdout and os.Stderr if they are nil.
+	output    io.Writer
+	errOutput io.Writer
 }
 
 // New creates and initialize a pointer on Flaeg
 func New(rootCommand *Command, args []string) *Flaeg {
 	var f Flaeg
-	f.commands = []*Command{rootCommand}
+	f.rootCommand = rootCommand
 	f.args = args
 	f.customParsers = map[reflect.Type]parse.Parser{}
+	f.notifySignals = signal.Notify
 	return &f
 }
 
 // AddCommand adds sub-command to the root command
 func (f *Flaeg) AddCommand(command *Command) {
-	f.commands = append(f.commands, command)
+	f.rootCommand.AddCommand(command)
 }
 
 // AddParser adds custom parser for a type to the map of custom parsers
@@ -625,6 +772,63 @@ func (f *Flaeg) AddParser(typ reflect.Type, parser parse.Parser) {
 	f.customParsers[typ] = parser
 }
 
+// AddSource adds a source of flags values with the given priority.
+// Flags given as arguments have the priority PriorityFlags.
+func (f *Flaeg) AddSource(src Source, priority int) {
+	f.sources = append(f.sources, layer{source: src, priority: priority})
+}
+
+// SetEnv adds a source of environment variables, overwritten by flags given as arguments
+func (f *Flaeg) SetEnv(env *EnvSource) {
+	f.AddSource(env, PriorityEnv)
+}
+
+// SetConfigFileFlag adds the built-in flag --configfile to the root command, giving the path of a configuration file.
+// The flag is persistent if the root command has persistent flags: the file is loaded for the called sub-command too.
+// The file at defaultPath is loaded if the flag is not called and if it exists.
+func (f *Flaeg) SetConfigFileFlag(defaultPath string) {
+	f.configFile = &defaultPath
+}
+
+// EnablePrintConfig adds the built-in flag --print-config:
+// it prints the value and the origin of every flag, then exits returning ErrPrintConfig.
+// The values are printed even if they are invalid, the ValidationError is returned instead.
+func (f *Flaeg) EnablePrintConfig() {
+	f.printConfig = true
+}
+
+// SetOutput prints the help and the config to w instead of os.Stdout
+func (f *Flaeg) SetOutput(w io.Writer) {
+	f.output = w
+}
+
+// SetErrOutput prints the errors, followed by the help, to w instead of os.Stderr
+func (f *Flaeg) SetErrOutput(w io.Writer) {
+	f.errOutput = w
+}
+
+// SetSilent prints nothing at all: the errors are only returned
+func (f *Flaeg) SetSilent() {
+	f.output = ioutil.Discard
+	f.errOutput = ioutil.Discard
+}
+
+// EnableStrictParsers refuses to run a command if a flagged field of its config has no parser, see CheckParsers
+func (f *Flaeg) EnableStrictParsers() {
+	f.strictParsers = true
+}
+
+// EnablePrefixMatching allows to call a sub-command with an unambiguous prefix of its name or of its aliases
+func (f *Flaeg) EnablePrefixMatching() {
+	f.prefixMatching = true
+}
+
+// Origins returns the origin of the value of every flag of the last parsed command:
+// OriginDefault, OriginDefaultPointer, OriginFlag or the name of a Source
+func (f *Flaeg) Origins() map[string]string {
+	return f.origins
+}
+
 // Run calls the command with flags given as arguments
 func (f *Flaeg) Run() error {
 	if f.calledCommand == nil {
@@ -636,7 +840,8 @@ func (f *Flaeg) Run() error {
 	if _, err := f.Parse(f.calledCommand); err != nil {
 		return err
 	}
-	return f.calledCommand.Run()
+
+	return f.runCommand(f.calledCommand)
 }
 
 // Parse calls Flaeg Load Function end returns the parsed command structure (by reference)
@@ -646,12 +851,43 @@ func (f *Flaeg) Parse(cmd *Command) (*Command, error) {
 		f.commandArgs = f.args
 	}
 
-	if err := LoadWithCommand(cmd, f.commandArgs, f.customParsers, f.commands); err != nil {
+	options := loadOptions{
+		sources:       f.sources,
+		printConfig:   f.printConfig,
+		strictParsers: f.strictParsers,
+		output:        f.output,
+		errOutput:     f.errOutput,
+	}
+	if f.hasConfigFileFlag(cmd) {
+		options.configFile = f.configFile
+	}
+
+	result, err := loadCommand(cmd, f.commandArgs, f.customParsers, nil, options)
+	f.errorPrinted = false
+	if result != nil {
+		f.origins = result.origins
+		f.positionalArgs = result.args
+		f.errorPrinted = result.errorPrinted
+	}
+	if err != nil {
 		return cmd, err
 	}
 	return cmd, nil
 }
 
+// hasConfigFileFlag returns true if cmd has the built-in flag --configfile:
+// the flag is on the root command, and it is persistent if the root command has persistent flags
+func (f *Flaeg) hasConfigFileFlag(cmd *Command) bool {
+	if f.configFile == nil {
+		return false
+	}
+	if cmd == f.rootCommand {
+		return true
+	}
+	persistentCommands := cmd.persistentCommands()
+	return len(persistentCommands) > 0 && persistentCommands[0] == f.rootCommand
+}
+
 // splitArgs takes args (type []string) and return command ("" if rootCommand) and command's args
 func splitArgs(args []string) (string, []string) {
 	if len(args) >= 1 && len(args[0]) >= 1 && string(args[0][0]) != "-" {
@@ -664,21 +900,51 @@ func splitArgs(args []string) (string, []string) {
 }
 
 // findCommandWithCommandArgs returns the called command (by reference) and command's args
+// Sub-commands are resolved level by level, from the root command.
 // the error returned is not nil if it fails
 func (f *Flaeg) findCommandWithCommandArgs() (*Command, []string, error) {
-	var commandName string
-	commandName, f.commandArgs = splitArgs(f.args)
-	if len(commandName) > 0 {
-		for _, command := range f.commands {
-			if commandName == command.Name {
-				f.calledCommand = command
-				return f.calledCommand, f.commandArgs, nil
+	command := f.rootCommand
+	f.commandArgs = f.args
+	for {
+		commandName, commandArgs := splitArgs(f.commandArgs)
+
+		// persistent flags may precede the command name
+		persistentCommands := command.persistentCommands()
+		if command.PersistentFlags {
+			persistentCommands = append(persistentCommands, command)
+		}
+		if len(persistentCommands) > 0 {
+			flagMap, err := persistentFlagMap(persistentCommands)
+			if err != nil {
+				return nil, []string{}, err
+			}
+			if f.hasConfigFileFlag(command) {
+				if err := addConfigFileFlag(flagMap, make(map[string]reflect.Value), *f.configFile); err != nil {
+					return nil, []string{}, err
+				}
 			}
+			commandName, commandArgs = splitPersistentArgs(f.commandArgs, flagMap)
 		}
-		return nil, []string{}, fmt.Errorf("command %s not found", commandName)
+
+		if len(commandName) == 0 {
+			break
+		}
+
+		subCommand, err := command.findSubCommand(commandName, f.prefixMatching)
+		if err != nil {
+			return nil, []string{}, err
+		}
+		if subCommand == nil {
+			// the command name is a positional argument
+			if command.hasArgs() {
+				break
+			}
+			return nil, []string{}, commandNotFoundError(command, commandName)
+		}
+		command, f.commandArgs = subCommand, commandArgs
 	}
 
-	f.calledCommand = f.commands[0]
+	f.calledCommand = command
 	return f.calledCommand, f.commandArgs, nil
 }
 
@@ -707,6 +973,10 @@ func isExported(fieldName string) bool {
 }
 
 func argToLower(inArg string) string {
+	// positional arguments are not changed
+	if !strings.HasPrefix(strings.TrimLeft(inArg, " "), "-") {
+		return inArg
+	}
 	if len(inArg) < 2 {
 		return strings.ToLower(inArg)
 	}
@@ -236,14 +236,30 @@ func TestLoadParsers(t *testing.T) {
 	check[reflect.TypeOf(true)] = &boolParser
 	var intParser parse.IntValue
 	check[reflect.TypeOf(1)] = &intParser
+	var int8Parser parse.Int8Value
+	check[reflect.TypeOf(int8(1))] = &int8Parser
+	var int16Parser parse.Int16Value
+	check[reflect.TypeOf(int16(1))] = &int16Parser
+	var int32Parser parse.Int32Value
+	check[reflect.TypeOf(int32(1))] = &int32Parser
 	var int64Parser parse.Int64Value
 	check[reflect.TypeOf(int64(1))] = &int64Parser
 	var uintParser parse.UintValue
 	check[reflect.TypeOf(uint(1))] = &uintParser
+	var uint8Parser parse.Uint8Value
+	check[reflect.TypeOf(uint8(1))] = &uint8Parser
+	var uint16Parser parse.Uint16Value
+	check[reflect.TypeOf(uint16(1))] = &uint16Parser
+	var uint32Parser parse.Uint32Value
+	check[reflect.TypeOf(uint32(1))] = &uint32Parser
 	var uint64Parser parse.Uint64Value
 	check[reflect.TypeOf(uint64(1))] = &uint64Parser
+	var uintptrParser parse.UintptrValue
+	check[reflect.TypeOf(uintptr(1))] = &uintptrParser
 	var stringParser parse.StringValue
 	check[reflect.TypeOf("")] = &stringParser
+	var float32Parser parse.Float32Value
+	check[reflect.TypeOf(float32(1.5))] = &float32Parser
 	var float64Parser parse.Float64Value
 	check[reflect.TypeOf(float64(1.5))] = &float64Parser
 	var durationParser parse.Duration
@@ -2950,3 +2966,87 @@ func TestTypoPrintHelp(t *testing.T) {
 		t.Errorf("Expected help description splitted on many line")
 	}
 }
+
+func TestFlaegRunOutputs(t *testing.T) {
+	testCases := []struct {
+		desc              string
+		args              []string
+		silent            bool
+		expectedErr       error
+		expectedOutput    string
+		expectedErrOutput string
+	}{
+		{
+			desc:           "help",
+			args:           []string{"--help"},
+			expectedErr:    pflag.ErrHelp,
+			expectedOutput: "Usage: flaegtest [flags]",
+		},
+		{
+			desc:              "error",
+			args:              []string{"--unknown"},
+			expectedErr:       errors.New("unknown flag --unknown"),
+			expectedErrOutput: "Error: unknown flag --unknown\nUsage: flaegtest [flags]",
+		},
+		{
+			desc:        "silent help",
+			args:        []string{"--help"},
+			silent:      true,
+			expectedErr: pflag.ErrHelp,
+		},
+		{
+			desc:        "silent error",
+			args:        []string{"--unknown"},
+			silent:      true,
+			expectedErr: errors.New("unknown flag --unknown"),
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &VersionConfig{},
+				DefaultPointersConfig: &VersionConfig{},
+				Run:                   func() error { return nil },
+			}
+
+			checkOutputs := func(t *testing.T, err error, output, errOutput *strings.Builder) {
+				if err == nil || err.Error() != test.expectedErr.Error() {
+					t.Errorf("Expected error %v got %v", test.expectedErr, err)
+				}
+				if !strings.HasPrefix(output.String(), test.expectedOutput) || len(test.expectedOutput) == 0 && output.Len() > 0 {
+					t.Errorf("Expected output %q got %q", test.expectedOutput, output)
+				}
+				if !strings.HasPrefix(errOutput.String(), test.expectedErrOutput) || len(test.expectedErrOutput) == 0 && errOutput.Len() > 0 {
+					t.Errorf("Expected error output %q got %q", test.expectedErrOutput, errOutput)
+				}
+			}
+
+			t.Run("Flaeg", func(t *testing.T) {
+				var output, errOutput strings.Builder
+				flaeg := New(rootCmd, test.args)
+				flaeg.SetOutput(&output)
+				flaeg.SetErrOutput(&errOutput)
+				if test.silent {
+					flaeg.SetSilent()
+				}
+
+				checkOutputs(t, flaeg.Run(), &output, &errOutput)
+			})
+
+			t.Run("LoadWithCommand", func(t *testing.T) {
+				var output, errOutput strings.Builder
+				opts := []Option{WithOutput(&output), WithErrOutput(&errOutput)}
+				if test.silent {
+					opts = append(opts, WithSilent())
+				}
+
+				checkOutputs(t, LoadWithCommand(rootCmd, test.args, nil, nil, opts...), &output, &errOutput)
+			})
+		})
+	}
+}
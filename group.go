@@ -0,0 +1,155 @@
+package flaeg
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// flagDependency is a flag which requires other flags
+type flagDependency struct {
+	flag     string
+	required []string
+}
+
+// AddExclusiveFlags declares flags which cannot be called together
+func (c *Command) AddExclusiveFlags(flags ...string) {
+	c.exclusiveFlags = append(c.exclusiveFlags, normalizeFlags(flags))
+}
+
+// AddFlagDependency declares that flag cannot be called without the required flags
+func (c *Command) AddFlagDependency(flag string, required ...string) {
+	c.flagDependencies = append(c.flagDependencies, flagDependency{
+		flag:     normalizeFlags([]string{flag})[0],
+		required: normalizeFlags(required),
+	})
+}
+
+// ExclusiveFlagsError is returned when a flag is enabled with flags which are mutually exclusive with it
+type ExclusiveFlagsError struct {
+	Flag string
+	// Conflicts are the other enabled flags of the group
+	Conflicts []string
+}
+
+func (e *ExclusiveFlagsError) Error() string {
+	return fmt.Sprintf("cannot be used with %s", joinFlags(e.Conflicts))
+}
+
+// MissingDependencyError is returned when a flag is enabled without the flags it requires
+type MissingDependencyError struct {
+	Flag string
+	// Missing are the required flags which are not enabled
+	Missing []string
+}
+
+func (e *MissingDependencyError) Error() string {
+	return fmt.Sprintf("requires %s", joinFlags(e.Missing))
+}
+
+// normalizeFlags returns flags names without dashes, in lower case
+func normalizeFlags(flags []string) []string {
+	normalized := make([]string, 0, len(flags))
+	for _, flg := range flags {
+		normalized = append(normalized, strings.ToLower(strings.TrimLeft(flg, "-")))
+	}
+	return normalized
+}
+
+// checkFlagGroups returns an error for every group of flags of cmd violated by the flags enabled in flagValMap.
+// A flag is enabled if it is called, unless it is a boolean flag set to false, e.g. --db=false,
+// and a pointer flag is enabled if one of its sub-flags is called.
+// It fails if a group contains an unknown flag.
+func checkFlagGroups(cmd *Command, flagMap map[string]reflect.StructField, flagValMap map[string]parse.Parser) ([]*FieldError, error) {
+	var groupErrors []*FieldError
+	for _, flags := range cmd.exclusiveFlags {
+		if err := checkGroupFlags(flagMap, flags...); err != nil {
+			return nil, err
+		}
+
+		if enabled := filterEnabledFlags(flagValMap, flags, true); len(enabled) > 1 {
+			groupErrors = append(groupErrors, &FieldError{Flag: enabled[0], Err: &ExclusiveFlagsError{Flag: enabled[0], Conflicts: enabled[1:]}})
+		}
+	}
+
+	for _, dependency := range cmd.flagDependencies {
+		if err := checkGroupFlags(flagMap, append([]string{dependency.flag}, dependency.required...)...); err != nil {
+			return nil, err
+		}
+		if !isFlagEnabled(flagValMap, dependency.flag) {
+			continue
+		}
+
+		if missing := filterEnabledFlags(flagValMap, dependency.required, false); len(missing) > 0 {
+			groupErrors = append(groupErrors, &FieldError{Flag: dependency.flag, Err: &MissingDependencyError{Flag: dependency.flag, Missing: missing}})
+		}
+	}
+
+	return groupErrors, nil
+}
+
+// isFlagEnabled returns true if flg is called in flagValMap, and is not a boolean flag set to false,
+// or if one of its sub-flags is called
+func isFlagEnabled(flagValMap map[string]parse.Parser, flg string) bool {
+	for visited, parser := range flagValMap {
+		if strings.HasPrefix(visited, flg+".") {
+			return true
+		}
+		if visited == flg {
+			if enabled, ok := parser.Get().(bool); !ok || enabled {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterEnabledFlags returns the flags enabled in flagValMap if enabled is true, the flags not enabled otherwise
+func filterEnabledFlags(flagValMap map[string]parse.Parser, flags []string, enabled bool) []string {
+	var filtered []string
+	for _, flg := range flags {
+		if isFlagEnabled(flagValMap, flg) == enabled {
+			filtered = append(filtered, flg)
+		}
+	}
+	return filtered
+}
+
+func checkGroupFlags(flagMap map[string]reflect.StructField, flags ...string) error {
+	for _, flg := range flags {
+		if _, ok := flagMap[flg]; !ok {
+			return &UnknownFlagError{Flag: flg, Source: "flags group"}
+		}
+	}
+	return nil
+}
+
+// joinFlags returns flags with dashes, separated by commas
+func joinFlags(flags []string) string {
+	return "--" + strings.Join(flags, ", --")
+}
+
+// printFlagGroups prints the groups of flags of cmd, if any
+func printFlagGroups(cmd *Command, output io.Writer) error {
+	if len(cmd.exclusiveFlags) == 0 && len(cmd.flagDependencies) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(output, "\nFlag groups:"); err != nil {
+		return err
+	}
+	for _, flags := range cmd.exclusiveFlags {
+		if _, err := fmt.Fprintf(output, "\t%s are mutually exclusive\n", joinFlags(flags)); err != nil {
+			return err
+		}
+	}
+	for _, dependency := range cmd.flagDependencies {
+		if _, err := fmt.Fprintf(output, "\t--%s requires %s\n", dependency.flag, joinFlags(dependency.required)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
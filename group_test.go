@@ -0,0 +1,149 @@
+package flaeg
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/containous/flaeg/parse"
+)
+
+func TestLoadWithCommandFlagGroups(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected []string
+	}{
+		{
+			desc: "no flags",
+		},
+		{
+			desc: "valid flags",
+			args: []string{"--db.ip=10.0.0.1", "--db.load=3"},
+		},
+		{
+			desc: "exclusive flags",
+			args: []string{"--db", "--owner", "--loglevel=INFO"},
+			expected: []string{
+				"--db: cannot be used with --owner",
+			},
+		},
+		{
+			desc: "exclusive pointer enabled by a sub-flag",
+			args: []string{"--db.load=3", "--owner.rate=0.5"},
+			expected: []string{
+				"--db: cannot be used with --owner",
+			},
+		},
+		{
+			desc: "exclusive flag disabled",
+			args: []string{"--db=false", "--owner"},
+		},
+		{
+			desc: "missing dependencies",
+			args: []string{"--db.ip=10.0.0.1"},
+			expected: []string{
+				"--db.ip: requires --db.load",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                newConfiguration(),
+				DefaultPointersConfig: newDefaultPointersConfiguration(),
+			}
+			cmd.AddExclusiveFlags("db", "owner")
+			cmd.AddFlagDependency("--db.ip", "--db.load")
+			customParsers := map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			}
+
+			err := LoadWithCommand(cmd, test.args, customParsers, nil)
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			validationError, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Expected a ValidationError got %v", err)
+			}
+			var errs []string
+			for _, fieldError := range validationError.Errors {
+				errs = append(errs, fieldError.Error())
+			}
+			if !reflect.DeepEqual(errs, test.expected) {
+				t.Errorf("\nexpected \t%q \ngot \t\t%q\n", test.expected, errs)
+			}
+		})
+	}
+}
+
+func TestLoadWithCommandFlagGroupsTypedErrors(t *testing.T) {
+	cmd := &Command{
+		Name:                  "flaegtest",
+		Config:                newConfiguration(),
+		DefaultPointersConfig: newDefaultPointersConfiguration(),
+	}
+	cmd.AddExclusiveFlags("db", "owner")
+	cmd.AddFlagDependency("db.ip", "db.load")
+	customParsers := map[reflect.Type]parse.Parser{
+		reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+	}
+
+	err := LoadWithCommand(cmd, []string{"--db.ip=10.0.0.1", "--owner"}, customParsers, nil)
+
+	var exclusiveErr *ExclusiveFlagsError
+	if !errors.As(err, &exclusiveErr) || exclusiveErr.Flag != "db" || !reflect.DeepEqual(exclusiveErr.Conflicts, []string{"owner"}) {
+		t.Errorf("Expected an ExclusiveFlagsError on --db got %v", err)
+	}
+	var dependencyErr *MissingDependencyError
+	if !errors.As(err, &dependencyErr) || dependencyErr.Flag != "db.ip" || !reflect.DeepEqual(dependencyErr.Missing, []string{"db.load"}) {
+		t.Errorf("Expected a MissingDependencyError on --db.ip got %v", err)
+	}
+}
+
+func TestLoadWithCommandFlagGroupsUnknownFlag(t *testing.T) {
+	cmd := &Command{
+		Name:                  "flaegtest",
+		Config:                newConfiguration(),
+		DefaultPointersConfig: newDefaultPointersConfiguration(),
+	}
+	cmd.AddFlagDependency("db.ip", "db.port")
+	customParsers := map[reflect.Type]parse.Parser{
+		reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+	}
+
+	err := LoadWithCommand(cmd, nil, customParsers, nil)
+
+	check := "unknown flag --db.port in flags group"
+	var unknownFlagErr *UnknownFlagError
+	if !errors.As(err, &unknownFlagErr) || err.Error() != check {
+		t.Errorf("Expected error %q got %v", check, err)
+	}
+}
+
+func TestPrintFlagGroups(t *testing.T) {
+	cmd := &Command{}
+	cmd.AddExclusiveFlags("db", "owner", "loglevel")
+	cmd.AddFlagDependency("tls.cert", "tls.key", "tls.ca")
+
+	var out bytes.Buffer
+	if err := printFlagGroups(cmd, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	check := "\nFlag groups:\n\t--db, --owner, --loglevel are mutually exclusive\n\t--tls.cert requires --tls.key, --tls.ca\n"
+	if out.String() != check {
+		t.Errorf("Got %q expected %q", out.String(), check)
+	}
+}
@@ -0,0 +1,93 @@
+package flaeg
+
+import "context"
+
+// RunFunc runs a command with the remaining positional arguments
+type RunFunc func(ctx context.Context, cmd *Command, args []string) error
+
+// Middleware wraps the run of the called command, e.g. to measure it
+type Middleware func(next RunFunc) RunFunc
+
+// Use adds middlewares around the run of the called command.
+// The first middleware added is the outermost one.
+func (f *Flaeg) Use(middlewares ...Middleware) {
+	f.middlewares = append(f.middlewares, middlewares...)
+}
+
+// runCommand runs cmd, once its config is loaded, with its hooks and through the middlewares:
+// - the PersistentPreRun hooks of the command and of its parents, from the root command
+// - the PreRun hook
+// - Run or RunWithContext, wrapped by the middlewares
+// - the PostRun hook
+// - the PersistentPostRun hooks of the command and of its parents, to the root command
+// If a pre-run hook fails, the command does not run.
+// The post-run hooks are called even if the command fails, the first error is returned.
+func (f *Flaeg) runCommand(cmd *Command) error {
+	var run RunFunc = func(ctx context.Context, cmd *Command, args []string) error {
+		if cmd.RunWithContext != nil {
+			return cmd.RunWithContext(ctx, args)
+		}
+		return cmd.Run()
+	}
+	for i := len(f.middlewares) - 1; i >= 0; i-- {
+		run = f.middlewares[i](run)
+	}
+
+	// the commands from the root command
+	commands := []*Command{cmd}
+	for parent := cmd.parent; parent != nil; parent = parent.parent {
+		commands = append([]*Command{parent}, commands...)
+	}
+
+	if err := preRun(commands); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if cmd.RunWithContext != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = f.signalContext()
+		defer cancel()
+	}
+	err := run(ctx, cmd, f.positionalArgs)
+
+	if errPostRun := postRun(commands); err == nil {
+		err = errPostRun
+	}
+	return err
+}
+
+// preRun calls the PersistentPreRun hooks of commands, from the root command to the called command,
+// then the PreRun hook of the called command, the last one.
+// It stops on the first error.
+func preRun(commands []*Command) error {
+	for _, command := range commands {
+		if command.PersistentPreRun != nil {
+			if err := command.PersistentPreRun(); err != nil {
+				return err
+			}
+		}
+	}
+	if cmd := commands[len(commands)-1]; cmd.PreRun != nil {
+		return cmd.PreRun()
+	}
+	return nil
+}
+
+// postRun calls the PostRun hook of the called command, the last one of commands,
+// then the PersistentPostRun hooks of commands to the root command.
+// Every hook is called, the first error is returned.
+func postRun(commands []*Command) error {
+	var err error
+	if cmd := commands[len(commands)-1]; cmd.PostRun != nil {
+		err = cmd.PostRun()
+	}
+	for i := len(commands) - 1; i >= 0; i-- {
+		if commands[i].PersistentPostRun != nil {
+			if errPostRun := commands[i].PersistentPostRun(); err == nil {
+				err = errPostRun
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package flaeg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFlaegRunHooks(t *testing.T) {
+	errHook := errors.New("hook error")
+
+	testCases := []struct {
+		desc        string
+		args        []string
+		failing     string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			desc: "root command",
+			expected: []string{
+				"root persistent pre-run", "root pre-run",
+				"middleware 1 before", "middleware 2 before", "root run", "middleware 2 after", "middleware 1 after",
+				"root post-run", "root persistent post-run",
+			},
+		},
+		{
+			desc: "sub-command",
+			args: []string{"version", "-v2.0"},
+			expected: []string{
+				"root persistent pre-run", "version persistent pre-run", "version pre-run 2.0",
+				"middleware 1 before", "middleware 2 before", "version run", "middleware 2 after", "middleware 1 after",
+				"version post-run", "version persistent post-run", "root persistent post-run",
+			},
+		},
+		{
+			desc:        "failing pre-run",
+			args:        []string{"version"},
+			failing:     "version persistent pre-run",
+			expected:    []string{"root persistent pre-run", "version persistent pre-run"},
+			expectedErr: errHook,
+		},
+		{
+			desc:    "failing run",
+			args:    []string{"version"},
+			failing: "version run",
+			expected: []string{
+				"root persistent pre-run", "version persistent pre-run", "version pre-run 0.1",
+				"middleware 1 before", "middleware 2 before", "version run", "middleware 2 after", "middleware 1 after",
+				"version post-run", "version persistent post-run", "root persistent post-run",
+			},
+			expectedErr: errHook,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var called []string
+			hook := func(name string) func() error {
+				return func() error {
+					called = append(called, name)
+					if name == test.failing {
+						return errHook
+					}
+					return nil
+				}
+			}
+			middleware := func(name string) Middleware {
+				return func(next RunFunc) RunFunc {
+					return func(ctx context.Context, cmd *Command, args []string) error {
+						called = append(called, name+" before")
+						err := next(ctx, cmd, args)
+						called = append(called, name+" after")
+						return err
+					}
+				}
+			}
+
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &VersionConfig{},
+				DefaultPointersConfig: &VersionConfig{},
+				Run:                   hook("root run"),
+				PreRun:                hook("root pre-run"),
+				PostRun:               hook("root post-run"),
+				PersistentPreRun:      hook("root persistent pre-run"),
+				PersistentPostRun:     hook("root persistent post-run"),
+			}
+			versionConfig := &VersionConfig{Version: "0.1"}
+			versionCmd := &Command{
+				Name:                  "version",
+				Config:                versionConfig,
+				DefaultPointersConfig: &VersionConfig{},
+				Run:                   hook("version run"),
+				PreRun: func() error {
+					// the config is loaded before the hooks
+					return hook("version pre-run " + versionConfig.Version)()
+				},
+				PostRun:           hook("version post-run"),
+				PersistentPreRun:  hook("version persistent pre-run"),
+				PersistentPostRun: hook("version persistent post-run"),
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.AddCommand(versionCmd)
+			flaeg.Use(middleware("middleware 1"), middleware("middleware 2"))
+
+			if err := flaeg.Run(); err != test.expectedErr {
+				t.Errorf("Expected error %v got %v", test.expectedErr, err)
+			}
+			if !reflect.DeepEqual(called, test.expected) {
+				t.Errorf("\nexpected \t%q \ngot \t\t%q\n", test.expected, called)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package flaeg
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// Option configures how LoadWithCommand loads a Command
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	sources []layer
+	// configFile is the default path of the built-in flag configfile, nil if the flag is disabled
+	configFile  *string
+	printConfig bool
+	// strictParsers refuses to load a command if a field has no parser
+	strictParsers bool
+	// output receives the help and the printed config, errOutput receives the errors.
+	// They are os.Stdout and os.Stderr if they are nil.
+	output    io.Writer
+	errOutput io.Writer
+}
+
+// writerOrDefault returns w, or defaultWriter if w is nil
+func writerOrDefault(w io.Writer, defaultWriter io.Writer) io.Writer {
+	if w == nil {
+		return defaultWriter
+	}
+	return w
+}
+
+func newLoadOptions(opts []Option) loadOptions {
+	var options loadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithSource loads the flags values from src as well, with the given priority.
+// Flags given as arguments have the priority PriorityFlags.
+func WithSource(src Source, priority int) Option {
+	return func(opts *loadOptions) {
+		opts.sources = append(opts.sources, layer{source: src, priority: priority})
+	}
+}
+
+// WithEnv loads the flags values from environment variables as well.
+// Flags given as arguments take precedence over environment variables.
+func WithEnv(env *EnvSource) Option {
+	return WithSource(env, PriorityEnv)
+}
+
+// WithConfigFileFlag adds the built-in flag --configfile giving the path of a configuration file (see FileSource).
+// The file at defaultPath is loaded if the flag is not called and if it exists.
+// Flags given as arguments and environment variables take precedence over the file.
+func WithConfigFileFlag(defaultPath string) Option {
+	return func(opts *loadOptions) {
+		opts.configFile = &defaultPath
+	}
+}
+
+// WithPrintConfigFlag adds the built-in flag --print-config:
+// it prints the value and the origin of every flag, then LoadWithCommand returns ErrPrintConfig.
+// The values are printed even if they are invalid, the ValidationError is returned instead.
+func WithPrintConfigFlag() Option {
+	return func(opts *loadOptions) {
+		opts.printConfig = true
+	}
+}
+
+// WithStrictParsers refuses to load the command if a flagged field has no parser, see CheckParsers.
+// By default, the other fields are loaded before the error is returned.
+func WithStrictParsers() Option {
+	return func(opts *loadOptions) {
+		opts.strictParsers = true
+	}
+}
+
+// WithOutput prints the help and the config to w instead of os.Stdout
+func WithOutput(w io.Writer) Option {
+	return func(opts *loadOptions) {
+		opts.output = w
+	}
+}
+
+// WithErrOutput prints the errors, followed by the help, to w instead of os.Stderr
+func WithErrOutput(w io.Writer) Option {
+	return func(opts *loadOptions) {
+		opts.errOutput = w
+	}
+}
+
+// WithSilent prints nothing at all: the errors are only returned
+func WithSilent() Option {
+	return func(opts *loadOptions) {
+		opts.output = ioutil.Discard
+		opts.errOutput = ioutil.Discard
+	}
+}
+
+// loadResult contains what is learned while loading a command
+type loadResult struct {
+	// origins links a flag with the origin of its value
+	origins map[string]string
+	// args are the positional arguments, if the command has no positional fields
+	args []string
+	// errorPrinted is true if the returned error has already been printed with the help
+	errorPrinted bool
+}
+
+// commandConfig is the config of a command with its flags
+type commandConfig struct {
+	objValue      reflect.Value
+	tagsMap       map[string]reflect.StructField
+	defaultValMap map[string]reflect.Value
+}
+
+func newCommandConfig(cmd *Command) (*commandConfig, error) {
+	config := &commandConfig{
+		objValue:      reflect.ValueOf(cmd.Config),
+		tagsMap:       make(map[string]reflect.StructField),
+		defaultValMap: make(map[string]reflect.Value),
+	}
+	if err := getTypesRecursive(config.objValue, config.tagsMap, ""); err != nil {
+		return nil, err
+	}
+	if err := getDefaultValue(config.objValue, reflect.ValueOf(cmd.DefaultPointersConfig), config.defaultValMap, ""); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// loadCommand initializes the config of cmd, and the configs of its parents with persistent flags,
+// from cmdArgs and from the sources of options.
+// If the load fails, the flagged fields of the configs are restored: the configs are left unchanged.
+// Without strict parsers, the configs are loaded even if a field has no parser.
+func loadCommand(cmd *Command, cmdArgs []string, customParsers map[reflect.Type]parse.Parser, subCommand []*Command, options loadOptions) (*loadResult, error) {
+	saved := saveFields(append([]*Command{cmd}, cmd.persistentCommands()...))
+	result, err := loadConfigs(cmd, cmdArgs, customParsers, subCommand, options)
+	if err != nil && err != ErrParserNotFound && err != ErrPrintConfig {
+		saved.restore()
+	}
+	return result, withFieldPath(err, cmd)
+}
+
+// savedField is the value of a flagged field before the load of its config
+type savedField struct {
+	field reflect.Value
+	value reflect.Value
+}
+
+type savedFields []savedField
+
+// saveFields returns the values of the flagged fields of the configs of commands.
+// Fields under nil pointers are not saved: the pointers are.
+func saveFields(commands []*Command) savedFields {
+	var saved savedFields
+	for _, command := range commands {
+		_ = visitFields(reflect.ValueOf(command.Config), "", func(_ string, _ reflect.StructField, fieldValue reflect.Value) error {
+			if fieldValue.CanSet() {
+				value := reflect.New(fieldValue.Type()).Elem()
+				value.Set(fieldValue)
+				saved = append(saved, savedField{field: fieldValue, value: value})
+			}
+			return nil
+		})
+	}
+	return saved
+}
+
+// restore sets the saved values back on their fields
+func (s savedFields) restore() {
+	for _, saved := range s {
+		saved.field.Set(saved.value)
+	}
+}
+
+// loadConfigs is loadCommand, without the path of the Go field in the errors
+func loadConfigs(cmd *Command, cmdArgs []string, customParsers map[reflect.Type]parse.Parser, subCommand []*Command, options loadOptions) (*loadResult, error) {
+	parsers, err := parse.LoadParsers(customParsers)
+	if err != nil {
+		return nil, err
+	}
+	output := writerOrDefault(options.output, os.Stdout)
+	errOutput := writerOrDefault(options.errOutput, os.Stderr)
+
+	configs, allTagsMap, err := commandConfigs(cmd, parsers, options)
+	if err != nil {
+		return nil, err
+	}
+	tagsMap, defaultValMap := configs[0].tagsMap, configs[0].defaultValMap
+
+	flagValMap, args, invalidValues, errParseArgs := parseFlagSet(cmdArgs, allTagsMap, parsers)
+	if errParseArgs != nil && !errors.Is(errParseArgs, ErrParserNotFound) {
+		return &loadResult{errorPrinted: true}, printErrorWithCommand(output, errOutput, errParseArgs, tagsMap, defaultValMap, parsers, cmd, subCommand)
+	}
+
+	argFields, err := getArgFields(cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	layerOrigins, fieldErrors, err := loadValues(cmd, configs, allTagsMap, flagValMap, parsers, options)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err = loadArgs(argFields, args, parsers)
+	if err != nil {
+		return &loadResult{errorPrinted: true}, printErrorWithCommand(output, errOutput, err, tagsMap, defaultValMap, parsers, cmd, subCommand)
+	}
+
+	// the invalid values of the flags are reported with the other errors on the values
+	errValidate := validateConfigs(configs, parsers, layerOrigins, append(invalidValues, fieldErrors...))
+
+	result, objValues := newLoadResult(configs, layerOrigins, options)
+	result.args = args
+
+	if isPrintConfigCalled(options, flagValMap) {
+		return result, printLoadedConfig(output, allTagsMap, parsers, result.origins, objValues, errValidate)
+	}
+	if errValidate != nil && len(invalidValues) > 0 {
+		// like the other errors of the arguments, the invalid arguments are printed with the help
+		return &loadResult{errorPrinted: true}, printErrorWithCommand(output, errOutput, errValidate, tagsMap, defaultValMap, parsers, cmd, subCommand)
+	}
+	if errValidate != nil {
+		return nil, errValidate
+	}
+
+	return result, errParseArgs
+}
+
+// commandConfigs returns the config of cmd, with the built-in flags enabled by options,
+// followed by the configs of its parents with persistent flags.
+// It returns the flags of all these configs as well: they are parsed together.
+// With strict parsers, it returns an error if a flag has no parser.
+func commandConfigs(cmd *Command, parsers map[reflect.Type]parse.Parser, options loadOptions) ([]*commandConfig, map[string]reflect.StructField, error) {
+	cmdConfig, err := newCommandConfig(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := addBuiltinFlags(cmdConfig, options); err != nil {
+		return nil, nil, err
+	}
+
+	configs, allTagsMap, err := withPersistentConfigs(cmd, cmdConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if options.strictParsers {
+		if err := missingParsers(allTagsMap, parsers); err != nil {
+			return nil, nil, err
+		}
+	}
+	return configs, allTagsMap, nil
+}
+
+// addBuiltinFlags adds the built-in flags enabled by options to the flags of config
+func addBuiltinFlags(config *commandConfig, options loadOptions) error {
+	if options.configFile != nil {
+		if err := addConfigFileFlag(config.tagsMap, config.defaultValMap, *options.configFile); err != nil {
+			return err
+		}
+	}
+	if options.printConfig {
+		if err := addPrintConfigFlag(config.tagsMap, config.defaultValMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withPersistentConfigs returns cmdConfig followed by the configs of the parents of cmd with persistent flags,
+// and the flags of all these configs: they are parsed together
+func withPersistentConfigs(cmd *Command, cmdConfig *commandConfig) ([]*commandConfig, map[string]reflect.StructField, error) {
+	configs := []*commandConfig{cmdConfig}
+	allTagsMap := make(map[string]reflect.StructField, len(cmdConfig.tagsMap))
+	for flg, field := range cmdConfig.tagsMap {
+		allTagsMap[flg] = field
+	}
+
+	for _, persistentCmd := range cmd.persistentCommands() {
+		persistentConfig, err := newCommandConfig(persistentCmd)
+		if err != nil {
+			return nil, nil, err
+		}
+		for flg, field := range persistentConfig.tagsMap {
+			if _, ok := allTagsMap[flg]; ok {
+				return nil, nil, &DuplicateFlagError{Flag: flg}
+			}
+			allTagsMap[flg] = field
+		}
+		configs = append(configs, persistentConfig)
+	}
+	return configs, allTagsMap, nil
+}
+
+// loadValues merges the flags values of flagValMap with the values of the sources of options, and fills configs with them.
+// It returns the origins of the values set by a layer, the values which cannot be set, and the flags breaking the groups of cmd.
+func loadValues(cmd *Command, configs []*commandConfig, allTagsMap map[string]reflect.StructField, flagValMap map[string]parse.Parser, parsers map[reflect.Type]parse.Parser, options loadOptions) (map[string]string, []*FieldError, error) {
+	groupErrors, err := checkFlagGroups(cmd, allTagsMap, flagValMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources := options.sources
+	if options.configFile != nil {
+		if fileLayer, ok := configFileLayer(flagValMap, *options.configFile); ok {
+			sources = append(sources, fileLayer)
+		}
+	}
+
+	valMap, layerOrigins, fieldErrors, err := mergeLayers(sources, flagValMap, allTagsMap, parsers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, config := range configs {
+		if err := fillStructRecursive(config.objValue, config.defaultValMap, valMap, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	return layerOrigins, append(fieldErrors, groupErrors...), nil
+}
+
+// loadArgs sets args on the positional fields argFields.
+// Commands without positional fields ignore the positional arguments: they are returned.
+func loadArgs(argFields []argField, args []string, parsers map[reflect.Type]parse.Parser) ([]string, error) {
+	if len(argFields) == 0 {
+		return args, nil
+	}
+	return nil, setArgs(argFields, args, parsers)
+}
+
+// validateConfigs returns a ValidationError listing fieldErrors, the required fields without value,
+// the values not satisfying their constraints, and the errors of the validators of configs
+func validateConfigs(configs []*commandConfig, parsers map[reflect.Type]parse.Parser, layerOrigins map[string]string, fieldErrors []*FieldError) error {
+	for _, config := range configs {
+		fieldErrors = append(fieldErrors, checkFields(config.objValue, parsers, layerOrigins, fieldErrors)...)
+	}
+	// cross-field rules are only checked on valid values
+	if len(fieldErrors) == 0 {
+		for _, config := range configs {
+			fieldErrors = append(fieldErrors, runValidators(config.objValue)...)
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return newValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// newLoadResult returns the result of the load of configs with the origins of their values, and the values of configs
+func newLoadResult(configs []*commandConfig, layerOrigins map[string]string, options loadOptions) (*loadResult, []reflect.Value) {
+	result := &loadResult{origins: make(map[string]string)}
+	var objValues []reflect.Value
+	for _, config := range configs {
+		for flg, origin := range getOrigins(config.objValue, config.tagsMap, config.defaultValMap, layerOrigins) {
+			result.origins[flg] = origin
+		}
+		objValues = append(objValues, config.objValue)
+	}
+	// built-in flags are not part of the config
+	if options.configFile != nil {
+		delete(result.origins, configFileFlag)
+	}
+	if options.printConfig {
+		delete(result.origins, printConfigFlag)
+	}
+	return result, objValues
+}
@@ -46,12 +46,53 @@ type BoolFlag interface {
 	IsBoolFlag() bool
 }
 
+// RangeError is returned when a numeric value does not fit in the bit size of its type
+type RangeError struct {
+	Value string
+	// Kind is the kind of the type, e.g. int8
+	Kind    reflect.Kind
+	BitSize int
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("value %q is out of range of %s (%d bits)", e.Value, e.Kind, e.BitSize)
+}
+
+// parseInt parses s like strconv.ParseInt(s, 0, bitSize): the base is given by the prefix of s.
+// It returns a RangeError if s does not fit in bitSize bits.
+func parseInt(s string, kind reflect.Kind, bitSize int) (int64, error) {
+	v, err := strconv.ParseInt(s, 0, bitSize)
+	return v, rangeError(err, s, kind, bitSize)
+}
+
+// parseUint parses s like strconv.ParseUint(s, 0, bitSize): the base is given by the prefix of s.
+// It returns a RangeError if s does not fit in bitSize bits.
+func parseUint(s string, kind reflect.Kind, bitSize int) (uint64, error) {
+	v, err := strconv.ParseUint(s, 0, bitSize)
+	return v, rangeError(err, s, kind, bitSize)
+}
+
+// parseFloat parses s like strconv.ParseFloat(s, bitSize).
+// It returns a RangeError if s does not fit in bitSize bits.
+func parseFloat(s string, kind reflect.Kind, bitSize int) (float64, error) {
+	v, err := strconv.ParseFloat(s, bitSize)
+	return v, rangeError(err, s, kind, bitSize)
+}
+
+// rangeError replaces the range error of strconv by a RangeError
+func rangeError(err error, s string, kind reflect.Kind, bitSize int) error {
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		return &RangeError{Value: s, Kind: kind, BitSize: bitSize}
+	}
+	return err
+}
+
 // IntValue int Value
 type IntValue int
 
 // Set sets int value from the given string value.
 func (i *IntValue) Set(s string) error {
-	v, err := strconv.ParseInt(s, 0, 64)
+	v, err := parseInt(s, reflect.Int, strconv.IntSize)
 	*i = IntValue(v)
 	return err
 }
@@ -66,12 +107,72 @@ func (i *IntValue) SetValue(val interface{}) {
 	*i = IntValue(val.(int))
 }
 
+// Int8Value int8 Value
+type Int8Value int8
+
+// Set sets int8 value from the given string value.
+func (i *Int8Value) Set(s string) error {
+	v, err := parseInt(s, reflect.Int8, 8)
+	*i = Int8Value(v)
+	return err
+}
+
+// Get returns the int8 value.
+func (i *Int8Value) Get() interface{} { return int8(*i) }
+
+func (i *Int8Value) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the Int8Value from the given int8-asserted value.
+func (i *Int8Value) SetValue(val interface{}) {
+	*i = Int8Value(val.(int8))
+}
+
+// Int16Value int16 Value
+type Int16Value int16
+
+// Set sets int16 value from the given string value.
+func (i *Int16Value) Set(s string) error {
+	v, err := parseInt(s, reflect.Int16, 16)
+	*i = Int16Value(v)
+	return err
+}
+
+// Get returns the int16 value.
+func (i *Int16Value) Get() interface{} { return int16(*i) }
+
+func (i *Int16Value) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the Int16Value from the given int16-asserted value.
+func (i *Int16Value) SetValue(val interface{}) {
+	*i = Int16Value(val.(int16))
+}
+
+// Int32Value int32 Value
+type Int32Value int32
+
+// Set sets int32 value from the given string value.
+func (i *Int32Value) Set(s string) error {
+	v, err := parseInt(s, reflect.Int32, 32)
+	*i = Int32Value(v)
+	return err
+}
+
+// Get returns the int32 value.
+func (i *Int32Value) Get() interface{} { return int32(*i) }
+
+func (i *Int32Value) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the Int32Value from the given int32-asserted value.
+func (i *Int32Value) SetValue(val interface{}) {
+	*i = Int32Value(val.(int32))
+}
+
 // Int64Value int64 Value
 type Int64Value int64
 
 // Set sets int64 value from the given string value.
 func (i *Int64Value) Set(s string) error {
-	v, err := strconv.ParseInt(s, 0, 64)
+	v, err := parseInt(s, reflect.Int64, 64)
 	*i = Int64Value(v)
 	return err
 }
@@ -91,7 +192,7 @@ type UintValue uint
 
 // Set sets uint value from the given string value.
 func (i *UintValue) Set(s string) error {
-	v, err := strconv.ParseUint(s, 0, 64)
+	v, err := parseUint(s, reflect.Uint, strconv.IntSize)
 	*i = UintValue(v)
 	return err
 }
@@ -106,12 +207,72 @@ func (i *UintValue) SetValue(val interface{}) {
 	*i = UintValue(val.(uint))
 }
 
+// Uint8Value uint8 Value
+type Uint8Value uint8
+
+// Set sets uint8 value from the given string value.
+func (i *Uint8Value) Set(s string) error {
+	v, err := parseUint(s, reflect.Uint8, 8)
+	*i = Uint8Value(v)
+	return err
+}
+
+// Get returns the uint8 value.
+func (i *Uint8Value) Get() interface{} { return uint8(*i) }
+
+func (i *Uint8Value) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the Uint8Value from the given uint8-asserted value.
+func (i *Uint8Value) SetValue(val interface{}) {
+	*i = Uint8Value(val.(uint8))
+}
+
+// Uint16Value uint16 Value
+type Uint16Value uint16
+
+// Set sets uint16 value from the given string value.
+func (i *Uint16Value) Set(s string) error {
+	v, err := parseUint(s, reflect.Uint16, 16)
+	*i = Uint16Value(v)
+	return err
+}
+
+// Get returns the uint16 value.
+func (i *Uint16Value) Get() interface{} { return uint16(*i) }
+
+func (i *Uint16Value) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the Uint16Value from the given uint16-asserted value.
+func (i *Uint16Value) SetValue(val interface{}) {
+	*i = Uint16Value(val.(uint16))
+}
+
+// Uint32Value uint32 Value
+type Uint32Value uint32
+
+// Set sets uint32 value from the given string value.
+func (i *Uint32Value) Set(s string) error {
+	v, err := parseUint(s, reflect.Uint32, 32)
+	*i = Uint32Value(v)
+	return err
+}
+
+// Get returns the uint32 value.
+func (i *Uint32Value) Get() interface{} { return uint32(*i) }
+
+func (i *Uint32Value) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the Uint32Value from the given uint32-asserted value.
+func (i *Uint32Value) SetValue(val interface{}) {
+	*i = Uint32Value(val.(uint32))
+}
+
 // Uint64Value uint64 Value
 type Uint64Value uint64
 
 // Set sets uint64 value from the given string value.
 func (i *Uint64Value) Set(s string) error {
-	v, err := strconv.ParseUint(s, 0, 64)
+	v, err := parseUint(s, reflect.Uint64, 64)
 	*i = Uint64Value(v)
 	return err
 }
@@ -126,6 +287,26 @@ func (i *Uint64Value) SetValue(val interface{}) {
 	*i = Uint64Value(val.(uint64))
 }
 
+// UintptrValue uintptr Value
+type UintptrValue uintptr
+
+// Set sets uintptr value from the given string value.
+func (i *UintptrValue) Set(s string) error {
+	v, err := parseUint(s, reflect.Uintptr, strconv.IntSize)
+	*i = UintptrValue(v)
+	return err
+}
+
+// Get returns the uintptr value.
+func (i *UintptrValue) Get() interface{} { return uintptr(*i) }
+
+func (i *UintptrValue) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets the UintptrValue from the given uintptr-asserted value.
+func (i *UintptrValue) SetValue(val interface{}) {
+	*i = UintptrValue(val.(uintptr))
+}
+
 // StringValue string Value
 type StringValue string
 
@@ -145,12 +326,32 @@ func (s *StringValue) SetValue(val interface{}) {
 	*s = StringValue(val.(string))
 }
 
+// Float32Value float32 Value
+type Float32Value float32
+
+// Set sets float32 value from the given string value.
+func (f *Float32Value) Set(s string) error {
+	v, err := parseFloat(s, reflect.Float32, 32)
+	*f = Float32Value(v)
+	return err
+}
+
+// Get returns the float32 value.
+func (f *Float32Value) Get() interface{} { return float32(*f) }
+
+func (f *Float32Value) String() string { return fmt.Sprintf("%v", *f) }
+
+// SetValue sets the Float32Value from the given float32-asserted value.
+func (f *Float32Value) SetValue(val interface{}) {
+	*f = Float32Value(val.(float32))
+}
+
 // Float64Value float64 Value
 type Float64Value float64
 
 // Set sets float64 value from the given string value.
 func (f *Float64Value) Set(s string) error {
-	v, err := strconv.ParseFloat(s, 64)
+	v, err := parseFloat(s, reflect.Float64, 64)
 	*f = Float64Value(v)
 	return err
 }
@@ -275,7 +476,8 @@ func (s *SliceStrings) SetValue(val interface{}) {
 
 // LoadParsers loads default parsers and custom parsers given as parameter.
 // Return a map [reflect.Type]parsers
-// bool, int, int64, uint, uint64, float64,
+// bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, string, float32, float64,
+// Duration, time.Time
 func LoadParsers(customParsers map[reflect.Type]Parser) (map[reflect.Type]Parser, error) {
 	parsers := map[reflect.Type]Parser{}
 
@@ -285,18 +487,42 @@ func LoadParsers(customParsers map[reflect.Type]Parser) (map[reflect.Type]Parser
 	var intParser IntValue
 	parsers[reflect.TypeOf(1)] = &intParser
 
+	var int8Parser Int8Value
+	parsers[reflect.TypeOf(int8(1))] = &int8Parser
+
+	var int16Parser Int16Value
+	parsers[reflect.TypeOf(int16(1))] = &int16Parser
+
+	var int32Parser Int32Value
+	parsers[reflect.TypeOf(int32(1))] = &int32Parser
+
 	var int64Parser Int64Value
 	parsers[reflect.TypeOf(int64(1))] = &int64Parser
 
 	var uintParser UintValue
 	parsers[reflect.TypeOf(uint(1))] = &uintParser
 
+	var uint8Parser Uint8Value
+	parsers[reflect.TypeOf(uint8(1))] = &uint8Parser
+
+	var uint16Parser Uint16Value
+	parsers[reflect.TypeOf(uint16(1))] = &uint16Parser
+
+	var uint32Parser Uint32Value
+	parsers[reflect.TypeOf(uint32(1))] = &uint32Parser
+
 	var uint64Parser Uint64Value
 	parsers[reflect.TypeOf(uint64(1))] = &uint64Parser
 
+	var uintptrParser UintptrValue
+	parsers[reflect.TypeOf(uintptr(1))] = &uintptrParser
+
 	var stringParser StringValue
 	parsers[reflect.TypeOf("")] = &stringParser
 
+	var float32Parser Float32Value
+	parsers[reflect.TypeOf(float32(1.5))] = &float32Parser
+
 	var float64Parser Float64Value
 	parsers[reflect.TypeOf(float64(1.5))] = &float64Parser
 
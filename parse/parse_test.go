@@ -400,3 +400,64 @@ func TestJsonUnmarshal(t *testing.T) {
 		t.Fatalf("Wrong value: %d instead of 10000000000", pointer.Timeout)
 	}
 }
+
+func TestNumericValuesSet(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		parser      Parser
+		in          string
+		expected    interface{}
+		expectedErr error
+	}{
+		{desc: "int", parser: new(IntValue), in: "-42", expected: -42},
+		{desc: "int8", parser: new(Int8Value), in: "-128", expected: int8(-128)},
+		{desc: "int8 hexadecimal", parser: new(Int8Value), in: "0x7f", expected: int8(127)},
+		{desc: "int8 overflow", parser: new(Int8Value), in: "128", expectedErr: &RangeError{Value: "128", Kind: reflect.Int8, BitSize: 8}},
+		{desc: "int16", parser: new(Int16Value), in: "-32768", expected: int16(-32768)},
+		{desc: "int16 overflow", parser: new(Int16Value), in: "-32769", expectedErr: &RangeError{Value: "-32769", Kind: reflect.Int16, BitSize: 16}},
+		{desc: "int32", parser: new(Int32Value), in: "0o17", expected: int32(15)},
+		{desc: "int32 overflow", parser: new(Int32Value), in: "2147483648", expectedErr: &RangeError{Value: "2147483648", Kind: reflect.Int32, BitSize: 32}},
+		{desc: "int64 overflow", parser: new(Int64Value), in: "9223372036854775808", expectedErr: &RangeError{Value: "9223372036854775808", Kind: reflect.Int64, BitSize: 64}},
+		{desc: "uint", parser: new(UintValue), in: "42", expected: uint(42)},
+		{desc: "uint8", parser: new(Uint8Value), in: "255", expected: uint8(255)},
+		{desc: "uint8 overflow", parser: new(Uint8Value), in: "0x100", expectedErr: &RangeError{Value: "0x100", Kind: reflect.Uint8, BitSize: 8}},
+		{desc: "uint16", parser: new(Uint16Value), in: "65535", expected: uint16(65535)},
+		{desc: "uint16 overflow", parser: new(Uint16Value), in: "65536", expectedErr: &RangeError{Value: "65536", Kind: reflect.Uint16, BitSize: 16}},
+		{desc: "uint32", parser: new(Uint32Value), in: "4294967295", expected: uint32(4294967295)},
+		{desc: "uint32 overflow", parser: new(Uint32Value), in: "4294967296", expectedErr: &RangeError{Value: "4294967296", Kind: reflect.Uint32, BitSize: 32}},
+		{desc: "uint64", parser: new(Uint64Value), in: "0xffffffffffffffff", expected: uint64(18446744073709551615)},
+		{desc: "uintptr", parser: new(UintptrValue), in: "0x1000", expected: uintptr(4096)},
+		{desc: "float32", parser: new(Float32Value), in: "1.5", expected: float32(1.5)},
+		{desc: "float32 overflow", parser: new(Float32Value), in: "1e39", expectedErr: &RangeError{Value: "1e39", Kind: reflect.Float32, BitSize: 32}},
+		{desc: "float64", parser: new(Float64Value), in: "1e39", expected: float64(1e39)},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.parser.Set(test.in)
+			if test.expectedErr != nil {
+				if !reflect.DeepEqual(err, test.expectedErr) {
+					t.Errorf("Expected error %v got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.parser.Get() != test.expected {
+				t.Errorf("Expected %v (%T) got %v (%T)", test.expected, test.expected, test.parser.Get(), test.parser.Get())
+			}
+		})
+	}
+}
+
+func TestNumericValuesSetInvalidSyntax(t *testing.T) {
+	var value Uint16Value
+	err := value.Set("port")
+	if _, ok := err.(*RangeError); err == nil || ok {
+		t.Errorf("Expected a syntax error got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package flaeg
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// kindTypes links the kinds of the scalar types with their built-in types
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Uintptr: reflect.TypeOf(uintptr(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
+
+// baseTypes links types with the types whose parsers parse them, before the fallback on the kind:
+// time.Duration is an int64 parsed as a parse.Duration
+var baseTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(time.Duration(0)): reflect.TypeOf(parse.Duration(0)),
+}
+
+// derivedParser is implemented by the parsers built by flaeg from other parsers or from types:
+// they cannot be cloned by copy, and they are not pointers on their values
+type derivedParser interface {
+	parse.Parser
+	// clone returns a new parser holding the same value
+	clone() parse.Parser
+	// value returns the value of the parser, convertible to the type of the field
+	value() reflect.Value
+}
+
+var (
+	parserType          = reflect.TypeOf((*parse.Parser)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// findParser returns the parser of typ, in this order:
+// - the parser registered for typ
+// - a new value of typ, if a pointer on typ implements parse.Parser: the type is its own parser
+// - a parser using Set and String, if a pointer on typ implements flag.Value
+// - a parser using UnmarshalText, if a pointer on typ implements encoding.TextUnmarshaler
+// - for a slice, a parser of its elements using the parser of their type
+// - for time.Duration, the parser registered for parse.Duration
+// - for a named scalar type, e.g. type LogLevel string, the parser registered for the built-in type of its kind
+func findParser(parsers map[reflect.Type]parse.Parser, typ reflect.Type) (parse.Parser, bool) {
+	if parser, ok := parsers[typ]; ok {
+		return parser, true
+	}
+
+	switch ptrType := reflect.PtrTo(typ); {
+	case ptrType.Implements(parserType):
+		return reflect.New(typ).Interface().(parse.Parser), true
+	case ptrType.Implements(flagValueType):
+		return &valueParser{ptrParser{ptr: reflect.New(typ)}}, true
+	case ptrType.Implements(textUnmarshalerType):
+		return &textParser{ptrParser{ptr: reflect.New(typ)}}, true
+	}
+
+	if typ.Kind() == reflect.Slice {
+		elem, ok := findParser(parsers, typ.Elem())
+		if !ok {
+			return nil, false
+		}
+		return &sliceParser{ptrParser: ptrParser{ptr: reflect.New(typ)}, elem: elem}, true
+	}
+
+	baseType, ok := baseTypes[typ]
+	if !ok {
+		baseType, ok = kindTypes[typ.Kind()]
+	}
+	if !ok {
+		return nil, false
+	}
+	parser, ok := parsers[baseType]
+	if !ok {
+		return nil, false
+	}
+	return &kindParser{Parser: parser, typ: baseType}, true
+}
+
+// parserValue returns the value held by parser
+func parserValue(parser parse.Parser) reflect.Value {
+	if derived, ok := parser.(derivedParser); ok {
+		return derived.value()
+	}
+	return reflect.ValueOf(parser).Elem()
+}
+
+// kindParser is the parser of a named scalar type, using the parser of the built-in type of its kind,
+// or the parser of a type of baseTypes
+type kindParser struct {
+	parse.Parser
+	// typ is the type parsed by Parser
+	typ reflect.Type
+}
+
+// SetValue sets the value val, converted to the type parsed by Parser
+func (p *kindParser) SetValue(val interface{}) {
+	p.Parser.SetValue(reflect.ValueOf(val).Convert(p.typ).Interface())
+}
+
+// IsBoolFlag returns true if the parser of the built-in type is a bool flag
+func (p *kindParser) IsBoolFlag() bool {
+	boolFlag, ok := p.Parser.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+func (p *kindParser) clone() parse.Parser {
+	return &kindParser{Parser: cloneParser(p.Parser), typ: p.typ}
+}
+
+func (p *kindParser) value() reflect.Value {
+	return parserValue(p.Parser)
+}
+
+// ptrParser holds the value of a parser built from the methods of a type
+type ptrParser struct {
+	// ptr is a pointer on the value
+	ptr reflect.Value
+}
+
+// Get returns the value
+func (p ptrParser) Get() interface{} { return p.ptr.Elem().Interface() }
+
+// SetValue sets the value val
+func (p ptrParser) SetValue(val interface{}) {
+	p.ptr.Elem().Set(reflect.ValueOf(val).Convert(p.ptr.Type().Elem()))
+}
+
+func (p ptrParser) copy() ptrParser {
+	ptr := reflect.New(p.ptr.Type().Elem())
+	ptr.Elem().Set(p.ptr.Elem())
+	return ptrParser{ptr: ptr}
+}
+
+func (p ptrParser) value() reflect.Value {
+	return p.ptr.Elem()
+}
+
+// valueParser is the parser of a type implementing flag.Value
+type valueParser struct {
+	ptrParser
+}
+
+// Set sets the value with the method Set of the type
+func (p *valueParser) Set(s string) error {
+	return p.ptr.Interface().(flag.Value).Set(s)
+}
+
+func (p *valueParser) String() string {
+	return p.ptr.Interface().(flag.Value).String()
+}
+
+// IsBoolFlag returns true if the type is a bool flag
+func (p *valueParser) IsBoolFlag() bool {
+	boolFlag, ok := p.ptr.Interface().(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+func (p *valueParser) clone() parse.Parser {
+	return &valueParser{p.copy()}
+}
+
+// textParser is the parser of a type implementing encoding.TextUnmarshaler.
+// Its value is printed with MarshalText if the type implements encoding.TextMarshaler.
+type textParser struct {
+	ptrParser
+}
+
+// Set sets the value with UnmarshalText
+func (p *textParser) Set(s string) error {
+	return p.ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+}
+
+func (p *textParser) String() string {
+	if p.ptr.Type().Implements(textMarshalerType) {
+		text, err := p.ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	}
+	return fmt.Sprint(p.Get())
+}
+
+func (p *textParser) clone() parse.Parser {
+	return &textParser{p.copy()}
+}
+
+// sliceParser is the parser of a slice, using the parser of its elements.
+// Every call of Set appends elements, so a repeated flag adds its values.
+type sliceParser struct {
+	ptrParser
+	// elem is the parser of the elements
+	elem parse.Parser
+}
+
+// Set appends the elements of s, split on , and ;
+func (p *sliceParser) Set(s string) error {
+	slice := p.ptr.Elem()
+	for _, str := range strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ';' }) {
+		elem := cloneParser(p.elem)
+		if err := elem.Set(str); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, parserValue(elem).Convert(slice.Type().Elem()))
+	}
+	p.ptr.Elem().Set(slice)
+	return nil
+}
+
+// String returns the elements printed by their parser, as a list
+func (p *sliceParser) String() string {
+	slice := p.ptr.Elem()
+	elems := make([]string, 0, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		elem := cloneParser(p.elem)
+		elem.SetValue(slice.Index(i).Interface())
+		elems = append(elems, elem.String())
+	}
+	return "[" + strings.Join(elems, " ") + "]"
+}
+
+func (p *sliceParser) clone() parse.Parser {
+	return &sliceParser{ptrParser: p.copy(), elem: p.elem}
+}
+
+// CheckParsers returns a ParsersNotFoundError listing every flagged field of the config of cmd,
+// and of the configs of its parents with persistent flags, whose type has neither a built-in parser nor a custom parser.
+// It returns nil if every field has a parser.
+func CheckParsers(cmd *Command, customParsers map[reflect.Type]parse.Parser) error {
+	parsers, err := parse.LoadParsers(customParsers)
+	if err != nil {
+		return err
+	}
+
+	flagMap := make(map[string]reflect.StructField)
+	for _, command := range append([]*Command{cmd}, cmd.persistentCommands()...) {
+		if err := getTypesRecursive(reflect.ValueOf(command.Config), flagMap, ""); err != nil {
+			return withFieldPath(err, cmd)
+		}
+	}
+
+	return withFieldPath(missingParsers(flagMap, parsers), cmd)
+}
+
+// missingParsers returns a ParsersNotFoundError listing the flags of flagMap without parser, sorted by flag.
+// The struct sections with flagged fields don't need a parser: their fields are the flags.
+// It returns nil if every flag has a parser.
+func missingParsers(flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser) error {
+	var parserErrors []*ParserNotFoundError
+	for flg, field := range flagMap {
+		if field.Type.Kind() == reflect.Struct && hasFlaggedFields(field.Type) {
+			continue
+		}
+		if _, ok := findParser(parsers, field.Type); !ok {
+			parserErrors = append(parserErrors, &ParserNotFoundError{Flag: flg, Type: field.Type})
+		}
+	}
+	if len(parserErrors) == 0 {
+		return nil
+	}
+
+	sort.Slice(parserErrors, func(i, j int) bool {
+		return parserErrors[i].Flag < parserErrors[j].Flag
+	})
+	return &ParsersNotFoundError{Errors: parserErrors}
+}
@@ -0,0 +1,658 @@
+package flaeg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg/parse"
+	"github.com/ogier/pflag"
+)
+
+type UnparsedConfig struct {
+	LogLevel string         `description:"Log level"`
+	Servers  []ServerInfo   `description:"Servers"`
+	Backend  *BackendInfo   `description:"Enable backend"`
+	Weights  map[string]int `description:"Weights"`
+}
+
+type BackendInfo struct {
+	URLs []string `description:"URLs"`
+}
+
+func TestCheckParsers(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		customParsers map[reflect.Type]parse.Parser
+		expected      error
+	}{
+		{
+			desc: "every missing parser",
+			expected: &ParsersNotFoundError{Errors: []*ParserNotFoundError{
+				{Flag: "servers", Field: "Servers", Type: reflect.TypeOf([]ServerInfo{})},
+				{Flag: "weights", Field: "Weights", Type: reflect.TypeOf(map[string]int{})},
+			}},
+		},
+		{
+			desc: "custom parsers",
+			customParsers: map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]string{}):     &parse.SliceStrings{},
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			},
+			expected: &ParsersNotFoundError{Errors: []*ParserNotFoundError{
+				{Flag: "weights", Field: "Weights", Type: reflect.TypeOf(map[string]int{})},
+			}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &UnparsedConfig{},
+				DefaultPointersConfig: &UnparsedConfig{},
+			}
+
+			err := CheckParsers(cmd, test.customParsers)
+			if !reflect.DeepEqual(err, test.expected) {
+				t.Errorf("\nexpected \t%v \ngot \t\t%v\n", test.expected, err)
+			}
+			if !errors.Is(err, ErrParserNotFound) {
+				t.Errorf("Expected %v to be %v", err, ErrParserNotFound)
+			}
+		})
+	}
+}
+
+func TestCheckParsersValid(t *testing.T) {
+	cmd := &Command{
+		Name:                  "flaegtest",
+		Config:                newConfiguration(),
+		DefaultPointersConfig: newDefaultPointersConfiguration(),
+	}
+
+	err := CheckParsers(cmd, map[reflect.Type]parse.Parser{reflect.TypeOf([]ServerInfo{}): &sliceServerValue{}})
+	if err != nil {
+		t.Errorf("Expected no error got %v", err)
+	}
+}
+
+func TestFlaegRunStrictParsers(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		strict           bool
+		expectedLogLevel string
+	}{
+		{
+			desc:             "partially filled config",
+			expectedLogLevel: "INFO",
+		},
+		{
+			desc:             "strict",
+			strict:           true,
+			expectedLogLevel: "DEBUG",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &UnparsedConfig{LogLevel: "DEBUG"}
+			called := false
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: &UnparsedConfig{},
+				Run: func() error {
+					called = true
+					return nil
+				},
+			}
+
+			flaeg := New(rootCmd, []string{"--loglevel=INFO"})
+			flaeg.SetSilent()
+			if test.strict {
+				flaeg.EnableStrictParsers()
+			}
+
+			err := flaeg.Run()
+			var parsersErr *ParsersNotFoundError
+			if test.strict && (!errors.As(err, &parsersErr) || len(parsersErr.Errors) != 2) {
+				t.Errorf("Expected 2 parsers not found got %v", err)
+			}
+			if !test.strict && err != ErrParserNotFound {
+				t.Errorf("Expected error %v got %v", ErrParserNotFound, err)
+			}
+			if called {
+				t.Error("The command must not run")
+			}
+			if config.LogLevel != test.expectedLogLevel {
+				t.Errorf("Expected log level %q got %q", test.expectedLogLevel, config.LogLevel)
+			}
+		})
+	}
+}
+
+type SectionConfig struct {
+	LogLevel string      `description:"Log level"`
+	Sub      SectionSub  `description:"Sub section"`
+	Optional *SectionSub `description:"Optional section"`
+}
+
+type SectionSub struct {
+	Port int `description:"Port"`
+}
+
+func TestFlaegRunStrictParsersSections(t *testing.T) {
+	config := &SectionConfig{}
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                config,
+		DefaultPointersConfig: &SectionConfig{Optional: &SectionSub{Port: 80}},
+		Run:                   func() error { return nil },
+	}
+
+	flaeg := New(rootCmd, []string{"--sub.port=3", "--optional"})
+	flaeg.SetSilent()
+	flaeg.EnableStrictParsers()
+
+	if err := flaeg.Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := &SectionConfig{Sub: SectionSub{Port: 3}, Optional: &SectionSub{Port: 80}}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", expected, config)
+	}
+
+	if err := CheckParsers(rootCmd, nil); err != nil {
+		t.Errorf("Expected no error got %v", err)
+	}
+}
+
+type LogLevel string
+
+type Port uint16
+
+type Enabled bool
+
+type NamedTypesConfig struct {
+	LogLevel LogLevel      `description:"Log level"`
+	Port     Port          `description:"Port"`
+	Debug    Enabled       `description:"Debug mode"`
+	Ports    []Port        `description:"Ports"`
+	Timeout  time.Duration `description:"Timeout"`
+}
+
+// upperLogLevel is a parser of LogLevel turning the values to upper case
+type upperLogLevel LogLevel
+
+func (l *upperLogLevel) Set(s string) error {
+	*l = upperLogLevel(strings.ToUpper(s))
+	return nil
+}
+
+func (l *upperLogLevel) Get() interface{} { return LogLevel(*l) }
+
+func (l *upperLogLevel) String() string { return string(*l) }
+
+func (l *upperLogLevel) SetValue(val interface{}) {
+	*l = upperLogLevel(val.(LogLevel))
+}
+
+func TestFlaegRunNamedTypes(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		args          []string
+		values        map[string]interface{}
+		customParsers map[reflect.Type]parse.Parser
+		expected      *NamedTypesConfig
+		expectedErr   string
+	}{
+		{
+			desc:     "defaults",
+			expected: &NamedTypesConfig{LogLevel: "info", Port: 80},
+		},
+		{
+			desc:     "kind parsers",
+			args:     []string{"--loglevel=debug", "--port=0x1f90", "--debug", "--ports=80,443"},
+			expected: &NamedTypesConfig{LogLevel: "debug", Port: 8080, Debug: true, Ports: []Port{80, 443}},
+		},
+		{
+			desc:     "duration",
+			args:     []string{"--timeout=5s"},
+			expected: &NamedTypesConfig{LogLevel: "info", Port: 80, Timeout: 5 * time.Second},
+		},
+		{
+			desc:     "values from a source",
+			values:   map[string]interface{}{"port": 8080, "timeout": time.Minute},
+			expected: &NamedTypesConfig{LogLevel: "info", Port: 8080, Timeout: time.Minute},
+		},
+		{
+			desc:          "registered parser first",
+			args:          []string{"--loglevel=debug"},
+			customParsers: map[reflect.Type]parse.Parser{reflect.TypeOf(LogLevel("")): new(upperLogLevel)},
+			expected:      &NamedTypesConfig{LogLevel: "DEBUG", Port: 80},
+		},
+		{
+			desc:        "out of range",
+			args:        []string{"--port=65536"},
+			expectedErr: "invalid configuration:\n\t" + `invalid argument "65536" for --port: value "65536" is out of range of uint16 (16 bits)`,
+		},
+		{
+			desc:        "out of range from a source",
+			values:      map[string]interface{}{"port": 70000},
+			expectedErr: "invalid configuration:\n\t" + `invalid value "70000" for --port from defaults: value "70000" is out of range of uint16 (16 bits)`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &NamedTypesConfig{LogLevel: "info", Port: 80}
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: &NamedTypesConfig{},
+				Run:                   func() error { return nil },
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.SetSilent()
+			flaeg.AddSource(NewMapSource("defaults", test.values), PriorityDefaults)
+			for typ, parser := range test.customParsers {
+				flaeg.AddParser(typ, parser)
+			}
+
+			err := flaeg.Run()
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Errorf("Expected error %q got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, config)
+			}
+		})
+	}
+}
+
+func TestPrintHelpNamedTypes(t *testing.T) {
+	config := &NamedTypesConfig{LogLevel: "info", Port: 80, Timeout: time.Minute}
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                config,
+		DefaultPointersConfig: &NamedTypesConfig{},
+	}
+
+	var output strings.Builder
+	err := LoadWithCommand(rootCmd, []string{"--help"}, nil, nil, WithOutput(&output))
+	if err != pflag.ErrHelp {
+		t.Fatalf("Expected error %v got %v", pflag.ErrHelp, err)
+	}
+
+	defaults := map[string]string{"--loglevel": `(default "info")`, "--port": `(default "80")`, "--timeout": `(default "1m0s")`}
+	for flg, defaultValue := range defaults {
+		found := false
+		for _, line := range strings.Split(output.String(), "\n") {
+			found = found || strings.Contains(line, flg+" ") && strings.Contains(line, defaultValue)
+		}
+		if !found {
+			t.Errorf("Expected %s %s in help:\n%s", flg, defaultValue, output.String())
+		}
+	}
+}
+
+// Color is a string enum implementing encoding.TextUnmarshaler and encoding.TextMarshaler
+type Color string
+
+func (c *Color) UnmarshalText(text []byte) error {
+	switch color := Color(strings.ToLower(string(text))); color {
+	case "red", "green", "blue":
+		*c = color
+		return nil
+	default:
+		return fmt.Errorf("unknown color %q", text)
+	}
+}
+
+func (c *Color) MarshalText() ([]byte, error) {
+	return []byte("color:" + string(*c)), nil
+}
+
+// Celsius only implements encoding.TextUnmarshaler
+type Celsius struct {
+	Degrees float64
+}
+
+func (c *Celsius) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%fC", &c.Degrees)
+	return err
+}
+
+type TextConfig struct {
+	IP          net.IP  `description:"IP address"`
+	Color       Color   `description:"Color"`
+	Temperature Celsius `description:"Temperature"`
+}
+
+func TestFlaegRunTextUnmarshalers(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		args        []string
+		expected    *TextConfig
+		expectedErr string
+	}{
+		{
+			desc:     "defaults",
+			expected: &TextConfig{IP: net.IPv4(127, 0, 0, 1), Color: "red"},
+		},
+		{
+			desc:     "values",
+			args:     []string{"--ip=10.0.0.1", "--color=Blue", "--temperature=21.5C"},
+			expected: &TextConfig{IP: net.IPv4(10, 0, 0, 1), Color: "blue", Temperature: Celsius{Degrees: 21.5}},
+		},
+		{
+			desc:        "invalid value",
+			args:        []string{"--color=pink"},
+			expectedErr: "invalid configuration:\n\t" + `invalid argument "pink" for --color: unknown color "pink"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &TextConfig{IP: net.IPv4(127, 0, 0, 1), Color: "red"}
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: &TextConfig{},
+				Run:                   func() error { return nil },
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.SetSilent()
+			flaeg.EnableStrictParsers()
+
+			err := flaeg.Run()
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Errorf("Expected error %q got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, config)
+			}
+		})
+	}
+}
+
+func TestPrintHelpTextMarshalers(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &TextConfig{IP: net.IPv4(127, 0, 0, 1), Color: "red", Temperature: Celsius{Degrees: 20}},
+		DefaultPointersConfig: &TextConfig{},
+	}
+
+	var output strings.Builder
+	err := LoadWithCommand(rootCmd, []string{"--help"}, nil, nil, WithOutput(&output))
+	if err != pflag.ErrHelp {
+		t.Fatalf("Expected error %v got %v", pflag.ErrHelp, err)
+	}
+
+	for _, check := range []string{`(default "127.0.0.1")`, `(default "color:red")`, `(default "{20}")`} {
+		if !strings.Contains(output.String(), check) {
+			t.Errorf("Expected %s in help:\n%s", check, output.String())
+		}
+	}
+}
+
+// Weights implements parse.Parser
+type Weights map[string]int
+
+func (w *Weights) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%q is not name=weight", s)
+	}
+	weight, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return err
+	}
+	if *w == nil {
+		*w = Weights{}
+	}
+	(*w)[parts[0]] = weight
+	return nil
+}
+
+func (w *Weights) Get() interface{} { return *w }
+
+func (w *Weights) String() string { return fmt.Sprint(map[string]int(*w)) }
+
+func (w *Weights) SetValue(val interface{}) {
+	*w = val.(Weights)
+}
+
+// Hosts implements flag.Value
+type Hosts []string
+
+func (h *Hosts) Set(s string) error {
+	*h = append(*h, s)
+	return nil
+}
+
+func (h *Hosts) String() string { return strings.Join(*h, ",") }
+
+// Verbosity implements flag.Value as a bool flag counting its calls
+type Verbosity int
+
+func (v *Verbosity) Set(string) error {
+	*v++
+	return nil
+}
+
+func (v *Verbosity) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *Verbosity) IsBoolFlag() bool { return true }
+
+type ValueConfig struct {
+	Weights Weights   `description:"Weights"`
+	Hosts   Hosts     `description:"Hosts"`
+	Verbose Verbosity `short:"v" description:"Verbosity"`
+}
+
+func TestFlaegRunParserTypes(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		args        []string
+		expected    *ValueConfig
+		expectedErr string
+	}{
+		{
+			desc:     "defaults",
+			expected: &ValueConfig{Hosts: Hosts{"localhost"}},
+		},
+		{
+			desc:     "values",
+			args:     []string{"--weights=a=1", "--weights=b=2", "--hosts=h1", "--hosts=h2", "-v", "--verbose"},
+			expected: &ValueConfig{Weights: Weights{"a": 1, "b": 2}, Hosts: Hosts{"h1", "h2"}, Verbose: 2},
+		},
+		{
+			desc:        "invalid value",
+			args:        []string{"--hosts=h1", "--weights=a"},
+			expected:    &ValueConfig{Hosts: Hosts{"localhost"}},
+			expectedErr: "invalid configuration:\n\t" + `invalid argument "a" for --weights: "a" is not name=weight`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &ValueConfig{Hosts: Hosts{"localhost"}}
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: &ValueConfig{},
+				Run:                   func() error { return nil },
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.SetSilent()
+			flaeg.EnableStrictParsers()
+
+			err := flaeg.Run()
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Errorf("Expected error %q got %v", test.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			// the config is left unchanged if the load fails
+			if !reflect.DeepEqual(config, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, config)
+			}
+		})
+	}
+}
+
+type SliceConfig struct {
+	Ports    []int            `description:"Ports"`
+	Timeouts []parse.Duration `description:"Timeouts"`
+	Rates    []float64        `description:"Rates"`
+	Colors   []Color          `description:"Colors"`
+	Levels   []LogLevel       `description:"Levels"`
+}
+
+func TestFlaegRunSlices(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		args          []string
+		env           map[string]string
+		customParsers map[reflect.Type]parse.Parser
+		expected      *SliceConfig
+		expectedErr   string
+	}{
+		{
+			desc:     "defaults",
+			expected: &SliceConfig{Ports: []int{80}},
+		},
+		{
+			desc: "separators",
+			args: []string{"--ports=80,443;8080", "--timeouts=1s,1m", "--rates=0.5", "--colors=Red,blue", "--levels=info,debug"},
+			expected: &SliceConfig{
+				Ports:    []int{80, 443, 8080},
+				Timeouts: []parse.Duration{parse.Duration(time.Second), parse.Duration(time.Minute)},
+				Rates:    []float64{0.5},
+				Colors:   []Color{"red", "blue"},
+				Levels:   []LogLevel{"info", "debug"},
+			},
+		},
+		{
+			desc:     "repeated flags",
+			args:     []string{"--ports=80", "--ports=443,8080", "--levels=info", "--levels=debug"},
+			expected: &SliceConfig{Ports: []int{80, 443, 8080}, Levels: []LogLevel{"info", "debug"}},
+		},
+		{
+			desc:     "environment variable",
+			env:      map[string]string{"FLAEGTEST_PORTS": "8080,8443"},
+			expected: &SliceConfig{Ports: []int{8080, 8443}},
+		},
+		{
+			desc:          "registered element parser",
+			args:          []string{"--levels=info,debug"},
+			customParsers: map[reflect.Type]parse.Parser{reflect.TypeOf(LogLevel("")): new(upperLogLevel)},
+			expected:      &SliceConfig{Ports: []int{80}, Levels: []LogLevel{"INFO", "DEBUG"}},
+		},
+		{
+			desc:        "invalid element",
+			args:        []string{"--ports=80,http"},
+			expectedErr: "invalid configuration:\n\t" + `invalid argument "80,http" for --ports: strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &SliceConfig{Ports: []int{80}}
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: &SliceConfig{},
+				Run:                   func() error { return nil },
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.SetSilent()
+			flaeg.EnableStrictParsers()
+			flaeg.SetEnv(&EnvSource{Prefix: "FLAEGTEST", LookupEnv: lookupEnvMap(test.env)})
+			for typ, parser := range test.customParsers {
+				flaeg.AddParser(typ, parser)
+			}
+
+			err := flaeg.Run()
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Errorf("Expected error %q got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.expected) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", test.expected, config)
+			}
+		})
+	}
+}
+
+func TestPrintHelpSlices(t *testing.T) {
+	rootCmd := &Command{
+		Name: "flaegtest",
+		Config: &SliceConfig{
+			Ports:    []int{80, 443},
+			Timeouts: []parse.Duration{parse.Duration(time.Second)},
+			Colors:   []Color{"red", "blue"},
+		},
+		DefaultPointersConfig: &SliceConfig{},
+	}
+
+	var output strings.Builder
+	err := LoadWithCommand(rootCmd, []string{"--help"}, nil, nil, WithOutput(&output))
+	if err != pflag.ErrHelp {
+		t.Fatalf("Expected error %v got %v", pflag.ErrHelp, err)
+	}
+
+	for _, check := range []string{`(default "[80 443]")`, `(default "[1s]")`, `(default "[color:red color:blue]")`, `(default "[]")`} {
+		if !strings.Contains(output.String(), check) {
+			t.Errorf("Expected %s in help:\n%s", check, output.String())
+		}
+	}
+}
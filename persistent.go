@@ -0,0 +1,107 @@
+package flaeg
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// persistentCommands returns the parents of the command with persistent flags, from the root command
+func (c *Command) persistentCommands() []*Command {
+	var commands []*Command
+	for parent := c.parent; parent != nil; parent = parent.parent {
+		if parent.PersistentFlags {
+			commands = append([]*Command{parent}, commands...)
+		}
+	}
+	return commands
+}
+
+// persistentFlagMap links the persistent flags of commands with their reflect.StructField
+func persistentFlagMap(commands []*Command) (map[string]reflect.StructField, error) {
+	flagMap := make(map[string]reflect.StructField)
+	for _, command := range commands {
+		if err := getTypesRecursive(reflect.ValueOf(command.Config), flagMap, ""); err != nil {
+			return nil, err
+		}
+	}
+	return flagMap, nil
+}
+
+// splitPersistentArgs is like splitArgs, but the command name may follow flags of flagMap.
+// These flags are returned in the command's args.
+func splitPersistentArgs(args []string, flagMap map[string]reflect.StructField) (string, []string) {
+	var flags []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) == 0 || arg[0] != '-' {
+			commandName, commandArgs := splitArgs(args[i:])
+			return commandName, append(flags, commandArgs...)
+		}
+
+		field, ok := lookupFlag(arg, flagMap)
+		if !ok {
+			break
+		}
+		flags = append(flags, arg)
+
+		// the value of a not boolean flag may be the next arg: --flag value, -f value
+		if !strings.Contains(arg, "=") && (strings.HasPrefix(arg, "--") || len(arg) == 2) &&
+			field.Type.Kind() != reflect.Bool && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return "", args
+}
+
+// lookupFlag returns the field of the flag called by arg (--flag[=value] or -f[value])
+func lookupFlag(arg string, flagMap map[string]reflect.StructField) (reflect.StructField, bool) {
+	if strings.HasPrefix(arg, "--") {
+		name := strings.ToLower(strings.SplitN(arg[2:], "=", 2)[0])
+		field, ok := flagMap[name]
+		return field, ok && len(name) > 0
+	}
+
+	if len(arg) < 2 {
+		return reflect.StructField{}, false
+	}
+	for _, field := range flagMap {
+		if field.Tag.Get("short") == arg[1:2] {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// printGlobalFlags prints the persistent flags inherited by cmd, if any
+func printGlobalFlags(cmd *Command, parsers map[reflect.Type]parse.Parser, output io.Writer) error {
+	commands := cmd.persistentCommands()
+	if len(commands) == 0 {
+		return nil
+	}
+
+	flagMap := make(map[string]reflect.StructField)
+	defaultValMap := make(map[string]reflect.Value)
+	for _, command := range commands {
+		config, err := newCommandConfig(command)
+		if err != nil {
+			return err
+		}
+		for flg, field := range config.tagsMap {
+			flagMap[flg] = field
+		}
+		for flg, defaultValue := range config.defaultValMap {
+			defaultValMap[flg] = defaultValue
+		}
+	}
+
+	if _, err := fmt.Fprintln(output, "\nGlobal Flags:"); err != nil {
+		return err
+	}
+	shortFlagsWithDash, flagsWithDash, descriptions, defaultValues := flagsDescriptionsDefaultValues(flagMap, defaultValMap, parsers)
+	return displayTab(output, shortFlagsWithDash, flagsWithDash, descriptions, defaultValues)
+}
@@ -0,0 +1,200 @@
+package flaeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type GlobalConfig struct {
+	LogLevel string `short:"l" description:"Log level"`
+	Verbose  bool   `description:"Verbose"`
+}
+
+func TestFlaegRunPersistentFlags(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		args            []string
+		expectedGlobal  GlobalConfig
+		expectedVersion string
+	}{
+		{
+			desc:            "root command",
+			args:            []string{"--loglevel=WARN"},
+			expectedGlobal:  GlobalConfig{LogLevel: "WARN"},
+			expectedVersion: "0.1",
+		},
+		{
+			desc:            "before the sub-command",
+			args:            []string{"--loglevel=WARN", "--verbose", "version", "-v2.0"},
+			expectedGlobal:  GlobalConfig{LogLevel: "WARN", Verbose: true},
+			expectedVersion: "2.0",
+		},
+		{
+			desc:            "value in the next arg",
+			args:            []string{"--loglevel", "WARN", "-l", "ERROR", "version"},
+			expectedGlobal:  GlobalConfig{LogLevel: "ERROR"},
+			expectedVersion: "0.1",
+		},
+		{
+			desc:            "after the sub-command",
+			args:            []string{"version", "--version=2.0", "-lWARN"},
+			expectedGlobal:  GlobalConfig{LogLevel: "WARN"},
+			expectedVersion: "2.0",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			globalConfig := &GlobalConfig{LogLevel: "INFO"}
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                globalConfig,
+				DefaultPointersConfig: &GlobalConfig{},
+				PersistentFlags:       true,
+				Run: func() error {
+					return nil
+				},
+			}
+			versionConfig := &VersionConfig{Version: "0.1"}
+			versionCmd := &Command{
+				Name:                  "version",
+				Config:                versionConfig,
+				DefaultPointersConfig: &VersionConfig{},
+				Run: func() error {
+					return nil
+				},
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.AddCommand(versionCmd)
+
+			if err := flaeg.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if *globalConfig != test.expectedGlobal {
+				t.Errorf("Expected global config %+v got %+v", test.expectedGlobal, *globalConfig)
+			}
+			if versionConfig.Version != test.expectedVersion {
+				t.Errorf("Expected version %s got %s", test.expectedVersion, versionConfig.Version)
+			}
+		})
+	}
+}
+
+func TestFlaegRunPersistentFlagsHelp(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &GlobalConfig{LogLevel: "INFO"},
+		DefaultPointersConfig: &GlobalConfig{},
+		PersistentFlags:       true,
+	}
+	versionCmd := &Command{
+		Name:                  "version",
+		Config:                &VersionConfig{Version: "0.1"},
+		DefaultPointersConfig: &VersionConfig{},
+	}
+
+	flaeg := New(rootCmd, []string{"version", "--help"})
+	flaeg.AddCommand(versionCmd)
+
+	// catch stdout
+	backupStdout := os.Stdout
+	defer func() {
+		os.Stdout = backupStdout
+	}()
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	_ = flaeg.Run()
+
+	// read and restore stdout
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = backupStdout
+
+	output := string(out)
+	globalFlags := strings.Index(output, "Global Flags:")
+	if globalFlags == -1 {
+		t.Fatalf("Expected Global Flags in help:\n%s", output)
+	}
+	if strings.Contains(output[:globalFlags], "--loglevel") {
+		t.Errorf("Global flags must not be listed with the flags of the command:\n%s", output)
+	}
+	if !strings.Contains(output[globalFlags:], `-l, --loglevel Log level (default "INFO")`) {
+		t.Errorf("Expected --loglevel in Global Flags:\n%s", output)
+	}
+}
+
+func TestFlaegRunPersistentConfigFile(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", "loglevel = \"WARN\"\nversion = \"2.0\"\n")
+	defer func() { _ = os.RemoveAll(filepath.Dir(path)) }()
+
+	testCases := []struct {
+		desc        string
+		defaultPath string
+		args        []string
+	}{
+		{
+			desc: "before the sub-command",
+			args: []string{"--configfile", path, "version"},
+		},
+		{
+			desc: "after the sub-command",
+			args: []string{"version", "--configfile=" + path},
+		},
+		{
+			desc:        "default path",
+			defaultPath: path,
+			args:        []string{"version"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := &GlobalConfig{LogLevel: "INFO"}
+			rootCmd := &Command{
+				Name:                  "flaegtest",
+				Config:                globalConfig,
+				DefaultPointersConfig: &GlobalConfig{},
+				PersistentFlags:       true,
+			}
+			versionConfig := &VersionConfig{Version: "0.1"}
+			versionCmd := &Command{
+				Name:                  "version",
+				Config:                versionConfig,
+				DefaultPointersConfig: &VersionConfig{},
+				Run: func() error {
+					return nil
+				},
+			}
+
+			flaeg := New(rootCmd, test.args)
+			flaeg.AddCommand(versionCmd)
+			flaeg.SetConfigFileFlag(test.defaultPath)
+
+			if err := flaeg.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if globalConfig.LogLevel != "WARN" {
+				t.Errorf("Expected log level WARN got %s", globalConfig.LogLevel)
+			}
+			if versionConfig.Version != "2.0" {
+				t.Errorf("Expected version 2.0 got %s", versionConfig.Version)
+			}
+		})
+	}
+}
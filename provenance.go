@@ -0,0 +1,135 @@
+package flaeg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// Origins of the flags values, a value loaded from a Source has the name of the Source as origin
+const (
+	OriginDefault        = "default"
+	OriginDefaultPointer = "default-pointer"
+	OriginFlag           = "flag"
+)
+
+// printConfigFlag is the name of the built-in flag printing the configuration
+const printConfigFlag = "print-config"
+
+// ErrPrintConfig is returned after the configuration has been printed by the flag --print-config
+var ErrPrintConfig = errors.New("print config requested")
+
+// getOrigins returns the origin of the value of every flag of flagMap.
+// layerOrigins contains the origins of the values set by a layer,
+// the other values come from the config or from the DefaultPointersConfig.
+func getOrigins(objValue reflect.Value, flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value, layerOrigins map[string]string) map[string]string {
+	// pointers set with their default value
+	var defaultPointers []string
+	_ = visitFields(objValue, "", func(name string, field reflect.StructField, fieldValue reflect.Value) error {
+		if fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+			if defVal, ok := defaultValMap[name]; ok && defVal.Kind() == reflect.Ptr && defVal.Pointer() == fieldValue.Pointer() {
+				defaultPointers = append(defaultPointers, name)
+			}
+		}
+		return nil
+	})
+
+	origins := make(map[string]string, len(flagMap))
+	for flg := range flagMap {
+		if origin, ok := layerOrigins[flg]; ok {
+			origins[flg] = origin
+			continue
+		}
+
+		origins[flg] = OriginDefault
+		for _, ptr := range defaultPointers {
+			if flg == ptr || strings.HasPrefix(flg, ptr+".") {
+				origins[flg] = OriginDefaultPointer
+				break
+			}
+		}
+	}
+	return origins
+}
+
+// addPrintConfigFlag adds the built-in flag print-config in flagMap, with its default value in defaultValMap
+func addPrintConfigFlag(flagMap map[string]reflect.StructField, defaultValMap map[string]reflect.Value) error {
+	if _, ok := flagMap[printConfigFlag]; ok {
+		return &DuplicateFlagError{Flag: printConfigFlag}
+	}
+
+	flagMap[printConfigFlag] = reflect.StructField{
+		Name: "PrintConfig",
+		Type: reflect.TypeOf(false),
+		Tag:  `description:"Print the configuration with the origin of the values and exit"`,
+	}
+	defaultValMap[printConfigFlag] = reflect.ValueOf(false)
+	return nil
+}
+
+// isPrintConfigCalled returns true if the built-in flag print-config is enabled by options and called in flagValMap
+func isPrintConfigCalled(options loadOptions, flagValMap map[string]parse.Parser) bool {
+	if !options.printConfig {
+		return false
+	}
+	parser, ok := flagValMap[printConfigFlag]
+	return ok && parser.Get().(bool)
+}
+
+// printLoadedConfig prints the loaded configs objValues, see printConfig, then returns ErrPrintConfig.
+// Invalid configs are printed as well, to find out where the invalid values come from: errValidate is returned instead.
+func printLoadedConfig(output io.Writer, flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser, origins map[string]string, objValues []reflect.Value, errValidate error) error {
+	if err := printConfig(output, flagMap, parsers, origins, objValues...); err != nil {
+		return err
+	}
+	if errValidate != nil {
+		return errValidate
+	}
+	return ErrPrintConfig
+}
+
+// printConfig prints the value and the origin of every flag of the configs objValues.
+// Flags under nil pointers are not printed.
+func printConfig(output io.Writer, flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser, origins map[string]string, objValues ...reflect.Value) error {
+	values := make(map[string]string)
+	for _, objValue := range objValues {
+		err := visitFields(objValue, "", func(name string, field reflect.StructField, fieldValue reflect.Value) error {
+			parser, ok := findParser(parsers, flagMap[name].Type)
+			if !ok {
+				return nil
+			}
+
+			parser = cloneParser(parser)
+			if fieldValue.Kind() == reflect.Ptr {
+				parser.SetValue(!fieldValue.IsNil())
+			} else {
+				parser.SetValue(fieldValue.Interface())
+			}
+			values[name] = parser.String()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	flags := make([]string, 0, len(values))
+	for flg := range values {
+		flags = append(flags, flg)
+	}
+	sort.Strings(flags)
+
+	var flagsWithDash, flagsValues, flagsOrigins []string
+	for _, flg := range flags {
+		flagsWithDash = append(flagsWithDash, "--"+flg)
+		flagsValues = append(flagsValues, fmt.Sprintf("%q", values[flg]))
+		flagsOrigins = append(flagsOrigins, "("+origins[flg]+")")
+	}
+
+	return displayTab(output, flagsWithDash, flagsValues, flagsOrigins)
+}
@@ -0,0 +1,143 @@
+package flaeg
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlaegOrigins(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                newConfiguration(),
+		DefaultPointersConfig: newDefaultPointersConfiguration(),
+		Run: func() error {
+			return nil
+		},
+	}
+
+	args := []string{
+		"--loglevel=INFO",
+		"--db.load=3",
+	}
+
+	flaeg := New(rootCmd, args)
+	flaeg.AddParser(reflect.TypeOf([]ServerInfo{}), &sliceServerValue{})
+	flaeg.SetEnv(&EnvSource{
+		LookupEnv: lookupEnvMap(map[string]string{"DB_IP": "10.0.0.1", "LOGLEVEL": "WARN"}),
+	})
+
+	if err := flaeg.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[string]string{
+		"loglevel":           OriginFlag,
+		"timeout":            OriginDefault,
+		"db":                 OriginDefaultPointer,
+		"db.watch":           OriginDefaultPointer,
+		"db.ip":              "env",
+		"db.load":            OriginFlag,
+		"db.load64":          OriginDefaultPointer,
+		"db.comax":           OriginDefaultPointer,
+		"db.connectionmax64": OriginDefaultPointer,
+		"owner":              OriginDefault,
+		"owner.name":         OriginDefault,
+		"owner.dob":          OriginDefault,
+		"owner.rate":         OriginDefault,
+		"owner.servers":      OriginDefault,
+	}
+
+	if origins := flaeg.Origins(); !reflect.DeepEqual(origins, check) {
+		t.Errorf("\nexpected \t%v \ngot \t\t%v\n", check, origins)
+	}
+}
+
+func TestFlaegPrintConfig(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                newConfiguration(),
+		DefaultPointersConfig: newDefaultPointersConfiguration(),
+		Run: func() error {
+			t.Error("the command must not run")
+			return nil
+		},
+	}
+
+	args := []string{
+		"--print-config",
+		"--db.load=3",
+	}
+
+	flaeg := New(rootCmd, args)
+	flaeg.AddParser(reflect.TypeOf([]ServerInfo{}), &sliceServerValue{})
+	flaeg.EnablePrintConfig()
+
+	// catch stdout
+	backupStdout := os.Stdout
+	defer func() {
+		os.Stdout = backupStdout
+	}()
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	if err := flaeg.Run(); err != ErrPrintConfig {
+		t.Errorf("Expected error %v got %v", ErrPrintConfig, err)
+	}
+
+	// read and restore stdout
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = backupStdout
+
+	checkLines := []string{
+		`--db                 "true"                          (default-pointer)`,
+		`--db.load            "3"                             (flag)`,
+		`--loglevel           "DEBUG"                         (default)`,
+		`--owner.dob          "1993-09-12 07:32:00 +0000 UTC" (default)`,
+		`--timeout            "1s"                            (default)`,
+	}
+	for _, line := range checkLines {
+		if !strings.Contains(string(out), line) {
+			t.Errorf("Expected line %q in output:\n%s", line, out)
+		}
+	}
+	if strings.Contains(string(out), "print-config") {
+		t.Errorf("Built-in flags must not be printed:\n%s", out)
+	}
+}
+
+func TestLoadWithCommandPrintConfigInvalid(t *testing.T) {
+	cmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &RequiredConfig{},
+		DefaultPointersConfig: &RequiredConfig{},
+	}
+
+	output := &bytes.Buffer{}
+	err := LoadWithCommand(cmd, []string{"--print-config", "--verbose"}, nil, nil, WithPrintConfigFlag(), WithOutput(output))
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 || validationErr.Errors[0].Flag != "name" {
+		t.Errorf("Expected the validation error of --name got %v", err)
+	}
+
+	checkLines := []string{
+		`--name    ""      (default)`,
+		`--verbose "true"  (flag)`,
+	}
+	for _, line := range checkLines {
+		if !strings.Contains(output.String(), line) {
+			t.Errorf("Expected line %q in output:\n%s", line, output)
+		}
+	}
+}
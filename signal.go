@@ -0,0 +1,36 @@
+package flaeg
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalNotifier relays incoming signals to c, like signal.Notify
+type SignalNotifier func(c chan<- os.Signal, sig ...os.Signal)
+
+// SetSignalNotifier replaces signal.Notify as source of the signals cancelling the context of RunWithContext
+func (f *Flaeg) SetSignalNotifier(notify SignalNotifier) {
+	f.notifySignals = notify
+}
+
+// signalContext returns a context cancelled on SIGINT or SIGTERM.
+// The signals are no longer relayed once the context is cancelled.
+func (f *Flaeg) signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	f.notifySignals(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	return ctx, cancel
+}
@@ -0,0 +1,72 @@
+package flaeg
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestFlaegRunWithContext(t *testing.T) {
+	var runArgs []string
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &VersionConfig{},
+		DefaultPointersConfig: &VersionConfig{},
+		RunWithContext: func(ctx context.Context, args []string) error {
+			runArgs = args
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	flaeg := New(rootCmd, []string{"-v1.0", "foo", "Bar"})
+	flaeg.SetSignalNotifier(func(c chan<- os.Signal, sig ...os.Signal) {
+		go func() {
+			c <- syscall.SIGTERM
+		}()
+	})
+
+	if err := flaeg.Run(); err != context.Canceled {
+		t.Errorf("Expected error %v got %v", context.Canceled, err)
+	}
+
+	check := []string{"foo", "Bar"}
+	if !reflect.DeepEqual(runArgs, check) {
+		t.Errorf("Expected args %q got %q", check, runArgs)
+	}
+}
+
+func TestFlaegRunWithContextSubCommand(t *testing.T) {
+	var called bool
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &VersionConfig{},
+		DefaultPointersConfig: &VersionConfig{},
+		RunWithContext: func(ctx context.Context, args []string) error {
+			t.Error("the root command must not run")
+			return nil
+		},
+	}
+	versionCmd := &Command{
+		Name:                  "version",
+		Config:                &VersionConfig{},
+		DefaultPointersConfig: &VersionConfig{},
+		Run: func() error {
+			called = true
+			return nil
+		},
+	}
+
+	flaeg := New(rootCmd, []string{"version"})
+	flaeg.AddCommand(versionCmd)
+	flaeg.SetSignalNotifier(func(c chan<- os.Signal, sig ...os.Signal) {})
+
+	if err := flaeg.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("Expected the version command to run")
+	}
+}
@@ -0,0 +1,207 @@
+package flaeg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// Priorities of the built-in layers.
+// The values of a layer overwrite the values of the layers of lower priority.
+const (
+	PriorityDefaults = 100
+	PriorityFile     = 200
+	PriorityEnv      = 300
+	PriorityFlags    = 400
+)
+
+// Source provides flags values from anywhere else than the command line arguments
+type Source interface {
+	// Name identifies the source in errors
+	Name() string
+	// Values returns the flags values found by the source, keyed by flag name (as GetFlags).
+	// A value is a string or a []string parsed by the flag parser,
+	// or any other value convertible to the field type.
+	Values(flagMap map[string]reflect.StructField) (map[string]interface{}, error)
+}
+
+// MapSource is a Source of values set programmatically
+type MapSource struct {
+	name   string
+	values map[string]interface{}
+}
+
+// NewMapSource creates a MapSource named name providing values
+func NewMapSource(name string, values map[string]interface{}) *MapSource {
+	return &MapSource{
+		name:   name,
+		values: values,
+	}
+}
+
+// Name returns the name of the MapSource
+func (m *MapSource) Name() string {
+	return m.name
+}
+
+// Values returns the values of the MapSource
+func (m *MapSource) Values(flagMap map[string]reflect.StructField) (map[string]interface{}, error) {
+	return m.values, nil
+}
+
+// layer is a Source registered with its priority, the flags layer has no source
+type layer struct {
+	source   Source
+	priority int
+}
+
+func (l layer) name() string {
+	if l.source == nil {
+		return OriginFlag
+	}
+	return l.source.Name()
+}
+
+// sortLayers returns the flags layer and the sources layers sorted by increasing priority.
+// Sources of the same priority keep their registration order.
+func sortLayers(sources []layer) []layer {
+	layers := append([]layer{{priority: PriorityFlags}}, sources...)
+	sort.SliceStable(layers, func(i, j int) bool {
+		if layers[i].priority == layers[j].priority {
+			// flags come after the sources of the same priority
+			return layers[j].source == nil
+		}
+		return layers[i].priority < layers[j].priority
+	})
+	return layers
+}
+
+// parseSource returns a map[flag]Parser of the values provided by a source, using parsers map[type]Parser.
+// The values which cannot be set are reported as FieldError.
+func parseSource(src Source, flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser) (map[string]parse.Parser, []*FieldError, error) {
+	values, err := src.Values(flagMap)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", src.Name(), err)
+	}
+
+	valMap := make(map[string]parse.Parser)
+	var fieldErrors []*FieldError
+	for flg, value := range values {
+		structField, ok := flagMap[flg]
+		if !ok {
+			fieldErrors = append(fieldErrors, &FieldError{Flag: flg, Err: &UnknownFlagError{Flag: flg, Source: src.Name()}})
+			continue
+		}
+
+		parser, ok := findParser(parsers, structField.Type)
+		if !ok {
+			fieldErrors = append(fieldErrors, &FieldError{Flag: flg, Err: ErrParserNotFound})
+			continue
+		}
+
+		newParser := cloneParser(parser)
+		if err := setParserValue(newParser, structField.Type, value); err != nil {
+			invalidValueErr := &InvalidValueError{Flag: flg, Value: fmt.Sprint(value), Source: src.Name(), Err: err}
+			fieldErrors = append(fieldErrors, &FieldError{Flag: flg, Err: invalidValueErr})
+			continue
+		}
+		valMap[flg] = newParser
+	}
+
+	return valMap, fieldErrors, nil
+}
+
+// setParserValue sets value into parser, parsing it if it is a string
+func setParserValue(parser parse.Parser, typ reflect.Type, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return parser.Set(v)
+	case []string:
+		for _, str := range v {
+			if err := parser.Set(str); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	val := reflect.ValueOf(value)
+	numberType := typ
+	if kind, ok := parser.(*kindParser); ok {
+		// named numeric types are parsed by the parser of their kind
+		numberType = kind.typ
+	}
+	// numbers are parsed to check that they fit in the built-in numeric types
+	if isNumber(val) && isNumberKind(numberType.Kind()) && len(numberType.PkgPath()) == 0 {
+		return parser.Set(fmt.Sprint(value))
+	}
+	if !val.IsValid() || !val.Type().ConvertibleTo(typ) || isLossyConversion(val, typ) {
+		return fmt.Errorf("%T is not convertible to %s", value, typ)
+	}
+	parser.SetValue(val.Convert(typ).Interface())
+	return nil
+}
+
+// isLossyConversion returns true if the conversion of val to typ changes the family of its kind,
+// e.g. an integer converted to a string, or if the converted number is not equal to val, e.g. an overflowing integer
+func isLossyConversion(val reflect.Value, typ reflect.Type) bool {
+	family := kindFamily(val.Kind())
+	if family != kindFamily(typ.Kind()) {
+		return true
+	}
+	if family != reflect.Int && family != reflect.Uint && family != reflect.Float64 {
+		return false
+	}
+	return val.Convert(typ).Convert(val.Type()).Interface() != val.Interface()
+}
+
+// kindFamily returns reflect.Int for the signed integers, reflect.Uint for the unsigned integers,
+// reflect.Float64 for the floats, and kind itself for the other kinds
+func kindFamily(kind reflect.Kind) reflect.Kind {
+	switch {
+	case reflect.Int <= kind && kind <= reflect.Int64:
+		return reflect.Int
+	case reflect.Uint <= kind && kind <= reflect.Uintptr:
+		return reflect.Uint
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		return reflect.Float64
+	}
+	return kind
+}
+
+// isNumber returns true if val is a built-in integer or float
+func isNumber(val reflect.Value) bool {
+	return val.IsValid() && isNumberKind(val.Kind()) && len(val.Type().PkgPath()) == 0
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return reflect.Int <= kind && kind <= reflect.Float64
+}
+
+// mergeLayers returns the map[flag]Parser of the flags values of every layer,
+// a value of a layer overwriting the values of the lower priority layers.
+// It returns the name of the layer of every value, and the values which cannot be set.
+func mergeLayers(sources []layer, flagValMap map[string]parse.Parser, flagMap map[string]reflect.StructField, parsers map[reflect.Type]parse.Parser) (map[string]parse.Parser, map[string]string, []*FieldError, error) {
+	valMap := make(map[string]parse.Parser)
+	origins := make(map[string]string)
+	var fieldErrors []*FieldError
+	for _, l := range sortLayers(sources) {
+		layerValMap := flagValMap
+		if l.source != nil {
+			var layerErrors []*FieldError
+			var err error
+			if layerValMap, layerErrors, err = parseSource(l.source, flagMap, parsers); err != nil {
+				return nil, nil, nil, err
+			}
+			fieldErrors = append(fieldErrors, layerErrors...)
+		}
+
+		for flg, parser := range layerValMap {
+			valMap[flg] = parser
+			origins[flg] = l.name()
+		}
+	}
+	return valMap, origins, fieldErrors, nil
+}
@@ -0,0 +1,218 @@
+package flaeg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg/parse"
+)
+
+func TestSortLayers(t *testing.T) {
+	defaults := NewMapSource("defaults", nil)
+	file := NewMapSource("file", nil)
+	overrides := NewMapSource("overrides", nil)
+	env := NewEnvSource("")
+
+	sources := []layer{
+		{source: overrides, priority: PriorityFlags},
+		{source: env, priority: PriorityEnv},
+		{source: defaults, priority: PriorityDefaults},
+		{source: file, priority: PriorityDefaults},
+	}
+
+	var names []string
+	for _, l := range sortLayers(sources) {
+		names = append(names, l.name())
+	}
+
+	check := []string{"defaults", "file", "env", "overrides", "flag"}
+	if !reflect.DeepEqual(names, check) {
+		t.Errorf("Got %s expected %s", names, check)
+	}
+}
+
+func TestLoadWithCommandSources(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		sources  []Option
+		expected func() *Configuration
+	}{
+		{
+			desc: "typed and string values",
+			sources: []Option{
+				WithSource(NewMapSource("defaults", map[string]interface{}{
+					"loglevel":   "WARN",
+					"timeout":    parse.Duration(3 * time.Second),
+					"owner.rate": float32(0.5),
+				}), PriorityDefaults),
+			},
+			expected: func() *Configuration {
+				check := newConfiguration()
+				check.LogLevel = "WARN"
+				check.Timeout = parse.Duration(3 * time.Second)
+				check.Owner.Rate = 0.5
+				return check
+			},
+		},
+		{
+			desc: "flags take precedence",
+			args: []string{"--loglevel=INFO"},
+			sources: []Option{
+				WithSource(NewMapSource("defaults", map[string]interface{}{
+					"loglevel": "WARN",
+				}), PriorityDefaults),
+			},
+			expected: func() *Configuration {
+				check := newConfiguration()
+				check.LogLevel = "INFO"
+				return check
+			},
+		},
+		{
+			desc: "source above flags",
+			args: []string{"--loglevel=INFO"},
+			sources: []Option{
+				WithSource(NewMapSource("overrides", map[string]interface{}{
+					"loglevel": "ERROR",
+				}), PriorityFlags+1),
+			},
+			expected: func() *Configuration {
+				check := newConfiguration()
+				check.LogLevel = "ERROR"
+				return check
+			},
+		},
+		{
+			desc: "higher priority source wins",
+			sources: []Option{
+				WithEnv(&EnvSource{LookupEnv: lookupEnvMap(map[string]string{"DB_LOAD": "7"})}),
+				WithSource(NewMapSource("defaults", map[string]interface{}{
+					"db.load": 3,
+					"db.ip":   "10.0.0.1",
+				}), PriorityDefaults),
+			},
+			expected: func() *Configuration {
+				check := newConfiguration()
+				check.Db = newDefaultPointersConfiguration().Db
+				check.Db.Load = 7
+				check.Db.IP = "10.0.0.1"
+				return check
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := newConfiguration()
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                config,
+				DefaultPointersConfig: newDefaultPointersConfiguration(),
+			}
+			customParsers := map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			}
+
+			if err := LoadWithCommand(cmd, test.args, customParsers, nil, test.sources...); err != nil {
+				t.Fatal(err)
+			}
+
+			if check := test.expected(); !reflect.DeepEqual(config, check) {
+				t.Errorf("\nexpected \t%+v \ngot \t\t%+v\n", check, config)
+			}
+		})
+	}
+}
+
+func TestLoadWithCommandSourceErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		values   map[string]interface{}
+		expected string
+	}{
+		{
+			desc:     "unknown flag",
+			values:   map[string]interface{}{"db.unknown": "1"},
+			expected: "unknown flag --db.unknown in defaults",
+		},
+		{
+			desc:     "not convertible value",
+			values:   map[string]interface{}{"db.load": time.Now()},
+			expected: "from defaults: time.Time is not convertible to int",
+		},
+		{
+			desc:     "number to string",
+			values:   map[string]interface{}{"loglevel": 65},
+			expected: "from defaults: int is not convertible to string",
+		},
+		{
+			desc:     "signed named number to unsigned",
+			values:   map[string]interface{}{"db.comax": time.Duration(-1)},
+			expected: "from defaults: time.Duration is not convertible to uint",
+		},
+		{
+			desc:     "out of range number",
+			values:   map[string]interface{}{"db.load": uint64(1 << 63)},
+			expected: `invalid value "9223372036854775808" for --db.load from defaults: value "9223372036854775808" is out of range of int`,
+		},
+		{
+			desc:     "invalid string",
+			values:   map[string]interface{}{"timeout": "forever"},
+			expected: `invalid value "forever" for --timeout from defaults`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                newConfiguration(),
+				DefaultPointersConfig: newDefaultPointersConfiguration(),
+			}
+			customParsers := map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			}
+
+			err := LoadWithCommand(cmd, nil, customParsers, nil, WithSource(NewMapSource("defaults", test.values), PriorityDefaults))
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error %q got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFlaegAddSource(t *testing.T) {
+	versionConfig := &VersionConfig{Version: "0.1"}
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                versionConfig,
+		DefaultPointersConfig: &VersionConfig{},
+		Run: func() error {
+			return nil
+		},
+	}
+
+	flaeg := New(rootCmd, []string{"-v3.0"})
+	flaeg.AddSource(NewMapSource("defaults", map[string]interface{}{"version": "2.0"}), PriorityDefaults)
+	flaeg.SetEnv(&EnvSource{
+		Prefix:    "FLAEGTEST",
+		LookupEnv: lookupEnvMap(map[string]string{"FLAEGTEST_VERSION": "2.5"}),
+	})
+
+	if err := flaeg.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if versionConfig.Version != "3.0" {
+		t.Errorf("Expected version 3.0 got %s", versionConfig.Version)
+	}
+}
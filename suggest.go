@@ -0,0 +1,95 @@
+package flaeg
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unknownFlagPrefix is the prefix of the error returned by pflag on an unknown long flag
+const unknownFlagPrefix = "unknown flag: --"
+
+// suggest returns the candidate the closest to name, false if no candidate is close enough:
+// the edit distance must not exceed a third of the length of name, with a minimum of 1
+func suggest(name string, candidates []string) (string, bool) {
+	sort.Strings(candidates)
+
+	maxDistance := len(name) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	suggestion, minDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if distance := editDistance(name, candidate); distance < minDistance {
+			suggestion, minDistance = candidate, distance
+		}
+	}
+	return suggestion, minDistance <= maxDistance
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a string, b string) int {
+	runesA, runesB := []rune(a), []rune(b)
+
+	previous := make([]int, len(runesB)+1)
+	current := make([]int, len(runesB)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(runesA); i++ {
+		current[0] = i
+		for j := 1; j <= len(runesB); j++ {
+			cost := 1
+			if runesA[i-1] == runesB[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(runesB)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// unknownFlagError converts the error of pflag on an unknown long flag into an UnknownFlagError,
+// with the closest flag of flagMap, if any
+func unknownFlagError(err error, flagMap map[string]reflect.StructField) error {
+	if !strings.HasPrefix(err.Error(), unknownFlagPrefix) {
+		return err
+	}
+
+	name := strings.TrimPrefix(err.Error(), unknownFlagPrefix)
+	flags := make([]string, 0, len(flagMap))
+	for flg := range flagMap {
+		flags = append(flags, flg)
+	}
+
+	suggestion, _ := suggest(name, flags)
+	return &UnknownFlagError{Flag: name, Suggestion: suggestion}
+}
+
+// commandNotFoundError returns the error of an unknown sub-command of cmd,
+// with the closest name or alias of the sub-commands which are not hidden, if any
+func commandNotFoundError(cmd *Command, name string) error {
+	var names []string
+	for _, command := range cmd.subCommands {
+		if !command.HideHelp {
+			names = append(names, command.Name)
+			names = append(names, command.Aliases...)
+		}
+	}
+
+	suggestion, _ := suggest(name, names)
+	return &UnknownCommandError{Command: name, Suggestion: suggestion}
+}
@@ -0,0 +1,148 @@
+package flaeg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containous/flaeg/parse"
+)
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "version", b: "version", expected: 0},
+		{a: "", b: "abc", expected: 3},
+		{a: "db.comx", b: "db.comax", expected: 1},
+		{a: "verison", b: "version", expected: 2},
+		{a: "kitten", b: "sitting", expected: 3},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.a+"/"+test.b, func(t *testing.T) {
+			t.Parallel()
+
+			if distance := editDistance(test.a, test.b); distance != test.expected {
+				t.Errorf("Expected %d got %d", test.expected, distance)
+			}
+		})
+	}
+}
+
+func TestParseArgsSuggestion(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc:     "close flag",
+			args:     []string{"--db.comx=3"},
+			expected: "unknown flag --db.comx, did you mean --db.comax?",
+		},
+		{
+			desc:     "upper case",
+			args:     []string{"--LogLevl=INFO"},
+			expected: "unknown flag --loglevl, did you mean --loglevel?",
+		},
+		{
+			desc:     "no close flag",
+			args:     []string{"--unknown"},
+			expected: "unknown flag --unknown",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			flagMap := make(map[string]reflect.StructField)
+			if err := getTypesRecursive(reflect.ValueOf(newConfiguration()), flagMap, ""); err != nil {
+				t.Fatal(err)
+			}
+			parsers, err := parse.LoadParsers(map[reflect.Type]parse.Parser{
+				reflect.TypeOf([]ServerInfo{}): &sliceServerValue{},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := parseArgs(test.args, flagMap, parsers); err == nil || err.Error() != test.expected {
+				t.Errorf("Expected error %q got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFlaegRunSuggestCommand(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc:     "name",
+			args:     []string{"verison"},
+			expected: "command verison not found, did you mean version?",
+		},
+		{
+			desc:     "alias",
+			args:     []string{"chek"},
+			expected: "command chek not found, did you mean check?",
+		},
+		{
+			desc:     "nested",
+			args:     []string{"version", "shw"},
+			expected: "command shw not found, did you mean show?",
+		},
+		{
+			desc:     "hidden",
+			args:     []string{"secert"},
+			expected: "command secert not found",
+		},
+		{
+			desc:     "too far",
+			args:     []string{"foo"},
+			expected: "command foo not found",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			newCommand := func(name string) *Command {
+				return &Command{
+					Name:                  name,
+					Config:                &VersionConfig{},
+					DefaultPointersConfig: &VersionConfig{},
+					Run: func() error {
+						return nil
+					},
+				}
+			}
+
+			versionCmd := newCommand("version")
+			versionCmd.AddCommand(newCommand("show"))
+			verifyCmd := newCommand("verify")
+			verifyCmd.Aliases = []string{"check"}
+			secretCmd := newCommand("secret")
+			secretCmd.HideHelp = true
+
+			flaeg := New(newCommand("flaegtest"), test.args)
+			flaeg.AddCommand(versionCmd)
+			flaeg.AddCommand(verifyCmd)
+			flaeg.AddCommand(secretCmd)
+
+			if err := flaeg.Run(); err == nil || err.Error() != test.expected {
+				t.Errorf("Expected error %q got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package flaeg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/containous/flaeg/parse"
+)
+
+// ErrRequired is the error of a required flag without value
+var ErrRequired = errors.New("required flag not set")
+
+// Validator is implemented by configurations checking their values, e.g. rules spanning several fields.
+// Validate is called on the config of a command, and on its enabled struct and pointer fields, once the values are loaded.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is an error on the value of a flag.
+// Flag is empty for an error on the whole config of a command.
+type FieldError struct {
+	Flag string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	switch e.Err.(type) {
+	case *UnknownFlagError, *InvalidValueError:
+		// the error names the flag already
+		return e.Err.Error()
+	}
+	if len(e.Flag) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("--%s: %v", e.Flag, e.Err)
+}
+
+// Unwrap returns the error on the value
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError aggregates the errors on the flags values of a command
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// newValidationError returns a ValidationError with fieldErrors sorted by flag
+func newValidationError(fieldErrors []*FieldError) *ValidationError {
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Flag < fieldErrors[j].Flag
+	})
+	return &ValidationError{Errors: fieldErrors}
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		msgs = append(msgs, "\t"+fieldError.Error())
+	}
+	return "invalid configuration:\n" + strings.Join(msgs, "\n")
+}
+
+// Is makes errors.Is(err, target) true if it is true for one of the field errors, e.g. with ErrRequired
+func (e *ValidationError) Is(target error) bool {
+	for _, fieldError := range e.Errors {
+		if errors.Is(fieldError, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first field error, or the first error of a field, matching target
+func (e *ValidationError) As(target interface{}) bool {
+	for _, fieldError := range e.Errors {
+		if errors.As(fieldError, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flags returns the flags in error
+func (e *ValidationError) Flags() []string {
+	flags := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		flags = append(flags, fieldError.Flag)
+	}
+	return flags
+}
+
+// checkFields returns an error for every field tagged `required:"true"` which has neither a value set by a layer
+// nor a non-zero value, and for every value which does not satisfy the constraints of its field (see checkConstraints).
+// Constraints are not checked on zero values which are not set by a layer.
+// Fields under nil pointers and flags with a value in error are not checked.
+func checkFields(objValue reflect.Value, parsers map[reflect.Type]parse.Parser, layerOrigins map[string]string, fieldErrors []*FieldError) []*FieldError {
+	inError := make(map[string]bool, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		inError[fieldError.Flag] = true
+	}
+
+	var checkErrors []*FieldError
+	_ = visitFields(objValue, "", func(name string, field reflect.StructField, fieldValue reflect.Value) error {
+		if inError[name] {
+			return nil
+		}
+
+		if _, ok := layerOrigins[name]; !ok && isZeroValue(fieldValue) {
+			if field.Tag.Get("required") == "true" {
+				checkErrors = append(checkErrors, &FieldError{Flag: name, Err: ErrRequired})
+			}
+			return nil
+		}
+
+		if err := checkConstraints(field, fieldValue, parsers); err != nil {
+			checkErrors = append(checkErrors, &FieldError{Flag: name, Err: err})
+		}
+		return nil
+	})
+	return checkErrors
+}
+
+// runValidators calls Validate on objValue and on every struct or not nil pointer field implementing Validator.
+// The errors are returned with the flag of the field.
+func runValidators(objValue reflect.Value) []*FieldError {
+	var validatorErrors []*FieldError
+	if err := validateValue(objValue); err != nil {
+		validatorErrors = append(validatorErrors, &FieldError{Err: err})
+	}
+
+	_ = visitFields(objValue, "", func(name string, field reflect.StructField, fieldValue reflect.Value) error {
+		if err := validateValue(fieldValue); err != nil {
+			validatorErrors = append(validatorErrors, &FieldError{Flag: name, Err: err})
+		}
+		return nil
+	})
+	return validatorErrors
+}
+
+// validateValue calls Validate if value is a struct or a not nil pointer on a struct implementing Validator
+func validateValue(value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() || value.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+	case reflect.Struct:
+		if value.CanAddr() {
+			value = value.Addr()
+		}
+	default:
+		return nil
+	}
+
+	if !value.CanInterface() {
+		return nil
+	}
+	if validator, ok := value.Interface().(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// isZeroValue returns true if value is the zero value of its type, or an empty slice or map
+func isZeroValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	default:
+		return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+	}
+}
@@ -0,0 +1,256 @@
+package flaeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/containous/flaeg/parse"
+)
+
+type RequiredConfig struct {
+	Name    string       `required:"true" description:"Name"`
+	Verbose bool         `required:"true" description:"Verbose"`
+	TLS     *RequiredTLS `description:"Enable TLS"`
+}
+
+type RequiredTLS struct {
+	Cert string `required:"true" description:"Certificate"`
+	Key  string `required:"true" description:"Key"`
+}
+
+func TestLoadWithCommandRequired(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		args          []string
+		sources       []Option
+		expectedFlags []string
+		// expectedUsage is true if the error is printed with the help
+		expectedUsage bool
+	}{
+		{
+			desc:          "missing flags",
+			expectedFlags: []string{"name", "verbose"},
+		},
+		{
+			desc: "zero value set by a flag",
+			args: []string{"--name=foo", "--verbose=false"},
+		},
+		{
+			desc:          "missing flags in enabled pointer",
+			args:          []string{"--name=foo", "--verbose", "--tls.cert=cert.pem"},
+			expectedFlags: []string{"tls.key"},
+		},
+		{
+			desc: "value set by a source",
+			args: []string{"--verbose"},
+			sources: []Option{
+				WithSource(NewMapSource("defaults", map[string]interface{}{"name": "foo"}), PriorityDefaults),
+			},
+		},
+		{
+			desc: "missing and invalid flags",
+			sources: []Option{
+				WithSource(NewMapSource("defaults", map[string]interface{}{
+					"verbose": "maybe",
+					"unknown": "1",
+				}), PriorityDefaults),
+			},
+			expectedFlags: []string{"name", "unknown", "verbose"},
+		},
+		{
+			desc:          "missing flags and invalid arguments",
+			args:          []string{"--verbose=maybe", "--tls=yes", "--tls.cert=cert.pem"},
+			expectedFlags: []string{"name", "tls", "tls.key", "verbose"},
+			expectedUsage: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &RequiredConfig{},
+				DefaultPointersConfig: &RequiredConfig{TLS: &RequiredTLS{}},
+			}
+
+			errOutput := &bytes.Buffer{}
+			err := LoadWithCommand(cmd, test.args, nil, nil, append(test.sources, WithErrOutput(errOutput))...)
+			if usage := strings.Contains(errOutput.String(), "Usage:"); usage != test.expectedUsage {
+				t.Errorf("Expected usage printed %t got %t:\n%s", test.expectedUsage, usage, errOutput)
+			}
+			if len(test.expectedFlags) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			validationError, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Expected a ValidationError got %v", err)
+			}
+			if flags := validationError.Flags(); !reflect.DeepEqual(flags, test.expectedFlags) {
+				t.Errorf("Got flags %s expected %s", flags, test.expectedFlags)
+			}
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := newValidationError([]*FieldError{
+		{Flag: "tls.key", Err: ErrRequired},
+		{Flag: "name", Err: ErrRequired},
+	})
+
+	check := "invalid configuration:\n\t--name: required flag not set\n\t--tls.key: required flag not set"
+	if err.Error() != check {
+		t.Errorf("Got %q expected %q", err.Error(), check)
+	}
+}
+
+func TestPrintHelpRequired(t *testing.T) {
+	config := &RequiredConfig{}
+	flagMap := make(map[string]reflect.StructField)
+	if err := getTypesRecursive(reflect.ValueOf(config), flagMap, ""); err != nil {
+		t.Fatal(err)
+	}
+	defaultValMap := make(map[string]reflect.Value)
+	if err := getDefaultValue(reflect.ValueOf(config), reflect.ValueOf(&RequiredConfig{}), defaultValMap, ""); err != nil {
+		t.Fatal(err)
+	}
+	parsers, err := parse.LoadParsers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := printFlagsDescriptionsDefaultValues(flagMap, defaultValMap, parsers, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), `--verbose  Verbose                            (default "false") (required)`) {
+		t.Errorf("Expected required flag in help:\n%s", out.String())
+	}
+}
+
+type ValidatedConfig struct {
+	Name  string          `description:"Name"`
+	Db    *ValidatedDb    `description:"Enable database"`
+	Owner *ValidatedOwner `description:"Enable owner"`
+}
+
+func (c *ValidatedConfig) Validate() error {
+	if c.Name == "root" {
+		return errors.New("root is reserved")
+	}
+	return nil
+}
+
+type ValidatedDb struct {
+	Load          int `description:"Load"`
+	ConnectionMax int `long:"comax" description:"Max connections"`
+}
+
+func (d *ValidatedDb) Validate() error {
+	if d.ConnectionMax < d.Load {
+		return fmt.Errorf("comax %d must be greater than load %d", d.ConnectionMax, d.Load)
+	}
+	return nil
+}
+
+type ValidatedOwner struct {
+	Name string `description:"Owner name"`
+}
+
+func (o ValidatedOwner) Validate() error {
+	if o.Name == "nobody" {
+		return errors.New("an owner is required")
+	}
+	return nil
+}
+
+func TestLoadWithCommandValidator(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc: "valid",
+			args: []string{"--db", "--db.load=5"},
+		},
+		{
+			desc: "disabled pointer is not validated",
+			args: []string{"--db.comax=0"},
+		},
+		{
+			desc:     "config",
+			args:     []string{"--name=root"},
+			expected: "invalid configuration:\n\troot is reserved",
+		},
+		{
+			desc:     "pointer and struct fields",
+			args:     []string{"--db.load=20", "--owner.name=nobody"},
+			expected: "invalid configuration:\n\t--db: comax 10 must be greater than load 20\n\t--owner: an owner is required",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &Command{
+				Name:                  "flaegtest",
+				Config:                &ValidatedConfig{},
+				DefaultPointersConfig: &ValidatedConfig{Db: &ValidatedDb{ConnectionMax: 10}, Owner: &ValidatedOwner{}},
+			}
+
+			err := LoadWithCommand(cmd, test.args, nil, nil)
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("Expected error %q got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFlaegRunValidator(t *testing.T) {
+	rootCmd := &Command{
+		Name:                  "flaegtest",
+		Config:                &VersionConfig{},
+		DefaultPointersConfig: &VersionConfig{},
+		Run: func() error {
+			return nil
+		},
+	}
+	subCmd := &Command{
+		Name:                  "sub",
+		Config:                &ValidatedConfig{},
+		DefaultPointersConfig: &ValidatedConfig{Db: &ValidatedDb{}},
+		Run: func() error {
+			t.Error("the command must not run")
+			return nil
+		},
+	}
+
+	flaeg := New(rootCmd, []string{"sub", "--name=root"})
+	flaeg.AddCommand(subCmd)
+
+	if _, ok := flaeg.Run().(*ValidationError); !ok {
+		t.Error("Expected a ValidationError")
+	}
+}